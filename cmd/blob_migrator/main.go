@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"telephone-book/internal/config"
+	"telephone-book/internal/storage/blob"
+	"telephone-book/internal/storage/postgresql"
+)
+
+// Переносит фотографии работников из колонки workers.photo в BlobStore из конфига.
+// Запуск: CONFIG_PATH=./config/local.yaml go run ./cmd/blob_migrator
+func main() {
+	var institutes string
+	var batchSize int
+
+	flag.StringVar(&institutes, "institutes", "grafit,giredmet", "comma separated institutes to migrate")
+	flag.IntVar(&batchSize, "batch-size", 100, "photos per batch")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+
+	ctx := context.Background()
+
+	blobStore, err := blob.New(ctx, cfg.Blob)
+	if err != nil {
+		panic(err)
+	}
+
+	storage, err := postgresql.New(cfg.StoragePath, blobStore)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Blob store: %s\n", cfg.Blob.Driver)
+
+	for _, institute := range strings.Split(institutes, ",") {
+		institute = strings.TrimSpace(institute)
+		if institute == "" {
+			continue
+		}
+
+		moved, err := storage.MovePhotosToBlobStore(ctx, institute, batchSize)
+		if err != nil {
+			panic(fmt.Errorf("%s: moved %d photos before error: %w", institute, moved, err))
+		}
+
+		fmt.Printf("%s: moved %d photos\n", institute, moved)
+	}
+
+	fmt.Println("photos moved successfully")
+}
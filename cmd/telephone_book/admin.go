@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"telephone-book/internal/bootstrap"
+	sso "telephone-book/internal/clients/sso/grpc"
+	"telephone-book/internal/config"
+	"telephone-book/internal/storage/blob"
+	"telephone-book/internal/storage/postgresql"
+
+	"golang.org/x/term"
+)
+
+const adminUsage = `usage: telephone_book admin create -email <email>
+
+Пароль берётся из переменной окружения ADMIN_PASSWORD, иначе запрашивается с терминала без эха
+или читается первой строкой stdin:
+  echo "$PASSWORD" | CONFIG_PATH=./config/local.yaml telephone_book admin create -email admin@example.com`
+
+// runAdmin служебные команды администратора; возвращает код выхода
+func runAdmin(args []string) int {
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	email := fs.String("email", "", "email администратора")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *email == "" {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	password, err := readAdminPassword()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read password:", err)
+		return 1
+	}
+	if len(password) < 8 {
+		fmt.Fprintln(os.Stderr, "password must be at least 8 characters")
+		return 2
+	}
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg.Env, cfg.Logger, cfg.LogRedaction)
+	ctx := context.Background()
+
+	ssoClient, err := sso.New(ctx, log, cfg.Clients.SSO.Address, cfg.Clients.SSO.Timeout, cfg.Clients.SSO.RetriesCount)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init sso client:", err)
+		return 1
+	}
+
+	blobStore, err := blob.New(ctx, cfg.Blob)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init blob store:", err)
+		return 1
+	}
+
+	storage, err := postgresql.New(cfg.StoragePath, blobStore)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init storage:", err)
+		return 1
+	}
+
+	userID, err := bootstrap.New(log, ssoClient, storage, cfg.Bootstrap).CreateAdmin(ctx, *email, password, bootstrap.MethodCLI)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create administrator:", err)
+		return 1
+	}
+
+	fmt.Printf("administrator %s created, user_id %d\n", *email, userID)
+	return 0
+}
+
+func readAdminPassword() (string, error) {
+	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+
+	// С терминала пароль читается без эха
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -4,33 +4,57 @@ package main
 // @version 1.0
 // @description API для телефонного справочника
 // @host
-// @BasePath /
+// @BasePath /api/v1
 
 import (
 	"context"
+	"errors"
 	stdlog "log"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	_ "telephone-book/docs" // swagger docs
+	"telephone-book/internal/apikey"
+	"telephone-book/internal/bootstrap"
 	sso "telephone-book/internal/clients/sso/grpc"
 	"telephone-book/internal/config"
+	"telephone-book/internal/http_server/handlers/apikeys"
 	"telephone-book/internal/http_server/handlers/auth/check_role"
 	"telephone-book/internal/http_server/handlers/auth/login"
+	"telephone-book/internal/http_server/handlers/auth/logout"
 	"telephone-book/internal/http_server/handlers/auth/register"
+	"telephone-book/internal/http_server/handlers/auth/sessions"
+	"telephone-book/internal/http_server/handlers/auth/setup"
 	"telephone-book/internal/http_server/handlers/auth/user_info"
 	"telephone-book/internal/http_server/handlers/departments"
+	"telephone-book/internal/http_server/handlers/health"
+	"telephone-book/internal/http_server/handlers/permissions"
 	"telephone-book/internal/http_server/handlers/utility/birthday"
 	"telephone-book/internal/http_server/handlers/utility/emergency"
 	imports "telephone-book/internal/http_server/handlers/utility/import"
+	"telephone-book/internal/http_server/handlers/utility/phonebook"
 	"telephone-book/internal/http_server/handlers/utility/search"
+	"telephone-book/internal/http_server/handlers/utility/vcard"
 	"telephone-book/internal/http_server/handlers/workers"
 	"telephone-book/internal/http_server/middleware"
+	"telephone-book/internal/importer"
+	"telephone-book/internal/lib/logger/redact"
 	"telephone-book/internal/lib/logger/sl"
 	"telephone-book/internal/lib/logger/slogpretty"
+	"telephone-book/internal/lib/pdf"
+	"telephone-book/internal/lib/token"
+	"telephone-book/internal/metrics"
+	"telephone-book/internal/photogc"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/ratelimit"
+	"telephone-book/internal/revocation"
+	"telephone-book/internal/rolecache"
+	"telephone-book/internal/storage/blob"
 	"telephone-book/internal/storage/postgresql"
-
-	"strings"
+	"telephone-book/internal/tracing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -38,6 +62,9 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// readyCheckTimeout сколько /readyz ждёт ответа зависимостей
+const readyCheckTimeout = 2 * time.Second
+
 const (
 	envLocal = "local"
 	envDev   = "dev"
@@ -45,14 +72,34 @@ const (
 )
 
 func main() {
+	// telephone_book admin create -email admin@example.com
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:]))
+	}
+
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env, cfg.Logger)
+	log := setupLogger(cfg.Env, cfg.Logger, cfg.LogRedaction)
 	log.With(
 		slog.String("env", cfg.Env),
 	)
 	log.Info("logger initialized successfully", slog.Any("cfg", cfg))
 
+	// Трассировка нужна до клиентов: перехватчик SSO берёт провайдер при вызове
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("failed to init tracing", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// SIGTERM (docker stop, Kubernetes) и Ctrl+C: перестаём принимать запросы и доделываем начатые
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Фоновые задачи живут до конца остановки HTTP сервера, а не до сигнала
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	ssoClient, err := sso.New(
 		context.Background(),
 		log,
@@ -66,95 +113,193 @@ func main() {
 
 	log.Info("sso client initialized successfully", slog.Any("sso", cfg.Clients.SSO))
 
-	// Создаем админа krawy при запуске с retry логикой
-	log.Info("creating default admin user krawy")
-	var adminCreated bool
-	for i := 0; i < 5; i++ {
-		_, err = ssoClient.Register(context.Background(), "krawy@krawy.ru", "krawy", "admin")
-		if err == nil {
-			log.Info("admin user krawy created successfully")
-			adminCreated = true
-			break
-		} else if strings.Contains(err.Error(), "already exists") {
-			log.Info("admin user krawy already exists")
-			adminCreated = true
-			break
-		} else {
-			log.Warn("failed to create admin user krawy, retrying...", sl.Err(err), slog.Int("attempt", i+1))
-			time.Sleep(time.Second * 5)
-		}
+	// Роли из SSO кешируются, чтобы не ходить в gRPC на каждый запрос
+	roleCache := rolecache.New(ssoClient, cfg.Auth.RoleCacheTTL, cfg.Auth.RoleCacheSize)
+
+	tokenParser, err := token.New(cfg.Auth)
+	if err != nil {
+		log.Error("failed to init token parser", sl.Err(err))
+		os.Exit(1)
 	}
-	if !adminCreated {
-		log.Error("failed to create admin user krawy after 5 attempts")
+
+	blobStore, err := blob.New(context.Background(), cfg.Blob)
+	if err != nil {
+		log.Error("failed to init blob store", sl.Err(err))
+		os.Exit(1)
 	}
 
-	storage, err := postgresql.New(cfg.StoragePath)
+	storage, err := connectStorage(ctx, log, cfg.StoragePath, blobStore, cfg.StorageWait)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
 		os.Exit(1)
 	}
 
+	// Токен живёт не дольше TokenTTL, плюс допустимое расхождение часов
+	revocations := revocation.New(log, storage, cfg.Auth.RevocationRefresh, cfg.Auth.TokenTTL+cfg.Auth.ClockSkew)
+	if err := revocations.Start(bgCtx); err != nil {
+		log.Error("failed to load revocation list", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// Первый администратор: один раз из конфига, иначе через /auth/setup или admin create
+	bootstrapper := bootstrap.New(log, ssoClient, storage, cfg.Bootstrap)
+	if err := bootstrapper.Run(ctx); err != nil {
+		log.Error("failed to bootstrap administrator", sl.Err(err))
+	}
+
+	// Ключи API сервисов, принимаются вместо токена в заголовке X-API-Key
+	apiKeys := apikey.New(log, storage, cfg.Auth.APIKeyTouchInterval)
+
+	// Права на институты и отделы; администраторам SSO разрешено всё
+	pol := policy.New(storage)
+
+	// Защита входа от перебора паролей и ограничение частоты публичных запросов
+	var loginStore ratelimit.Store
+	switch cfg.RateLimit.Login.Store {
+	case "memory":
+		loginStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		loginStore = storage
+	default:
+		log.Error("unknown login rate limit store", slog.String("store", cfg.RateLimit.Login.Store))
+		os.Exit(1)
+	}
+	loginGuard := ratelimit.NewLoginGuard(log, loginStore, cfg.RateLimit.Login)
+	loginGuard.Start(bgCtx)
+
+	// Фото удаляются не сразу, а после выдержки, см. postgresql.Storage.releasePhoto
+	photogc.New(log, storage, cfg.Blob).Start(bgCtx)
+
+	// Без шрифтов не работает только выгрузка в PDF: она отвечает 503, остальное API доступно
+	pdfGenerator, err := pdf.New(cfg.PDF.FontPath, cfg.PDF.BoldFontPath)
+	if err != nil {
+		log.Error("failed to init pdf generator, pdf export disabled", sl.Err(err))
+	}
+
+	importManager := importer.New(log, storage, storage, storage, cfg.Import.Workers, cfg.Import.QueueSize, cfg.Import.Lease, cfg.Import.PollInterval)
+	if err := importManager.Start(bgCtx); err != nil {
+		log.Error("failed to start import manager", sl.Err(err))
+		os.Exit(1)
+	}
+
 	router := chi.NewRouter()
 
 	router.Use(chimiddleware.RequestID) // tracing
-	router.Use(chimiddleware.Logger)
+	if cfg.RateLimit.TrustProxy {
+		router.Use(chimiddleware.RealIP)
+	}
+	router.Use(middleware.Tracing)
+	router.Use(middleware.AccessLog(log))
+	router.Use(middleware.Metrics) // до Recoverer, чтобы паника попала в статистику как 500
 	router.Use(chimiddleware.Recoverer)
 	router.Use(chimiddleware.URLFormat)
-	router.Use(middleware.CORS)                           // Добавляем CORS middleware
-	router.Use(middleware.AuthMiddleware(ssoClient, log)) // Добавляем Auth middleware
+	router.Use(middleware.CORS)                                                              // Добавляем CORS middleware
+	router.Use(middleware.AuthMiddleware(roleCache, tokenParser, revocations, apiKeys, log)) // Добавляем Auth middleware
+
+	// Метрики Prometheus
+	metrics.RegisterDBStats(storage.Stats)
+	metrics.RegisterRoleCacheStats(roleCache.Stats)
+	router.Get("/metrics", metrics.Handler().ServeHTTP)
 
 	// Swagger UI
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
-	ctx := context.Background()
-
-	// SSO
-	router.Route("/auth", func(r chi.Router) {
-		r.Post("/login", login.New(ctx, ssoClient, log))
-		r.Post("/register", register.New(ctx, ssoClient, log))
-		r.Get("/check-role", check_role.CheckRole(ctx, log))
-		r.Get("/user-info", user_info.UserInfo(ctx, log))
-	})
-
-	// Срочные службы
-	router.Route("/emergency", func(r chi.Router) {
-		r.Get("/", emergency.New(ctx, log, storage))
-
-	})
-
-	// Поиск
-	router.Route("/search", func(r chi.Router) {
-		r.Get("/", search.New(ctx, log, storage))
-	})
-
-	//Др сегодня и завтра
-	router.Route("/birthday", func(r chi.Router) {
-		r.Get("/today", birthday.Today(ctx, log, storage))
-		r.Get("/tomorrow", birthday.Tomorrow(ctx, log, storage))
-	})
+	// Пробы Docker/Kubernetes: жив ли процесс и можно ли направлять на него запросы
+	var draining atomic.Bool
+	router.Get("/healthz", health.Live())
+	router.Get("/readyz", health.Ready(log, readyCheckTimeout, map[string]health.Check{
+		"postgres": storage.Ping,
+		"sso": func(context.Context) error {
+			if ssoClient == nil {
+				return errors.New("sso client is not initialized")
+			}
+			return ssoClient.Ready()
+		},
+		"shutdown": func(context.Context) error {
+			if draining.Load() {
+				return errors.New("server is shutting down")
+			}
+			return nil
+		},
+	}))
+
+	h := apiHandlers{
+		searchLimit: middleware.RateLimit(ratelimit.NewClientLimiter(cfg.RateLimit.Search.RPS, cfg.RateLimit.Search.Burst), log),
+		photoLimit:  middleware.RateLimit(ratelimit.NewClientLimiter(cfg.RateLimit.Photos.RPS, cfg.RateLimit.Photos.Burst), log),
+
+		authenticated: middleware.RequireAuth(log),
+
+		deadline:       middleware.Deadline(cfg.HTTPServer.Deadlines.Default),
+		searchDeadline: middleware.Deadline(cfg.HTTPServer.Deadlines.Search),
+		photoDeadline:  middleware.Deadline(cfg.HTTPServer.Deadlines.Photos),
+		importDeadline: middleware.Deadline(cfg.HTTPServer.Deadlines.Import),
+		exportDeadline: middleware.Deadline(cfg.HTTPServer.Deadlines.Export),
+
+		login:          login.New(ssoClient, log, cfg.Auth.AppID, loginGuard),
+		register:       register.New(ssoClient, log),
+		setup:          setup.New(log, bootstrapper),
+		checkRole:      check_role.CheckRole(log),
+		userInfo:       user_info.UserInfo(log),
+		logout:         logout.New(log, revocations, roleCache),
+		revokeSessions: sessions.Revoke(log, revocations, roleCache, pol),
+
+		emergency:         emergency.New(log, storage),
+		search:            search.New(log, storage),
+		birthdaysToday:    birthday.Today(log, storage),
+		birthdaysTomorrow: birthday.Tomorrow(log, storage),
+
+		listWorkers:           workers.GetAll(log, storage),
+		createWorker:          workers.Create(log, storage, pol),
+		createWorkerWithPhoto: workers.CreateWithPhoto(log, storage, pol, cfg.Moderation),
+		getWorker:             workers.GetByEmail(log, storage),
+		updateWorker:          workers.Update(log, storage, pol),
+		deleteWorker:          workers.Delete(log, storage, pol),
+		workerVCard:           vcard.Worker(log, storage),
+
+		getPhoto:     workers.GetPhoto(log, storage),
+		getPhotoByID: workers.GetPhotoByID(log, storage),
+		uploadPhoto:  workers.UploadPhoto(log, storage, pol, cfg.Moderation),
+		updatePhoto:  workers.UpdatePhoto(log, storage, pol, cfg.Moderation),
+		deletePhoto:  workers.DeletePhoto(log, storage, pol),
+		cropPhoto:    workers.CropPhoto(log, storage, pol),
+		importPhotos: workers.ImportPhotos(log, storage, pol, cfg.Moderation),
+		pendingList:  workers.GetPendingPhotos(log, storage, pol),
+		pendingPhoto: workers.GetPendingPhoto(log, storage, pol),
+		approvePhoto: workers.ApprovePhoto(log, storage, pol),
+		rejectPhoto:  workers.RejectPhoto(log, storage, pol),
+
+		importWorkers: imports.New(log, importManager, pol),
+		importVCard:   imports.VCard(log, importManager, pol),
+		importStatus:  imports.Status(log, storage, pol),
+		importCancel:  imports.Cancel(log, storage, importManager, pol),
+		importReport:  imports.Report(log, storage, pol),
+
+		exportVCard: vcard.Export(log, storage),
+		exportPDF:   phonebook.PDF(log, storage, pdfGenerator),
+
+		listDepartments:    departments.GetAll(log, storage),
+		createDepartment:   departments.Create(log, storage, pol),
+		updateDepartment:   departments.Update(log, storage, pol),
+		deleteDepartment:   departments.Delete(log, storage, pol),
+		departmentSections: departments.GetSections(log, storage),
+
+		listAPIKeys:  apikeys.List(log, storage, pol),
+		createAPIKey: apikeys.Create(log, apiKeys, pol),
+		revokeAPIKey: apikeys.Revoke(log, storage, pol),
+
+		listPermissions:  permissions.List(log, storage, pol),
+		grantPermission:  permissions.Create(log, storage, pol),
+		revokePermission: permissions.Delete(log, storage, pol),
+	}
 
-	//Работники
-	router.Route("/workers", func(r chi.Router) {
-		r.Post("/", workers.Create(ctx, log, storage))
-		r.Post("/with-photo", workers.CreateWithPhoto(ctx, log, storage))
-		r.Get("/{email}", workers.GetByEmail(ctx, log, storage))
-		r.Get("/{email}/photo", workers.GetPhoto(ctx, log, storage))
-		r.Post("/{email}/photo", workers.UploadPhoto(ctx, log, storage))
-		r.Put("/{email}/photo", workers.UpdatePhoto(ctx, log, storage))
-		r.Delete("/{email}/photo", workers.DeletePhoto(ctx, log, storage))
-		r.Put("/", workers.Update(ctx, log, storage))
-		r.Delete("/", workers.Delete(ctx, log, storage))
-		r.Post("/all", workers.GetAll(ctx, log, storage))
-		r.Post("/import", imports.New(ctx, log, storage))
+	router.Route("/api/v1", func(r chi.Router) {
+		apiV1(r, h)
 	})
 
-	// Отделы
-	router.Route("/departments", func(r chi.Router) {
-		r.Get("/", departments.GetAll(ctx, log, storage))
-		r.Post("/", departments.Create(ctx, log, storage))
-		r.Put("/", departments.Update(ctx, log, storage))
-		r.Delete("/", departments.Delete(ctx, log, storage))
-		r.Get("/{department}", departments.GetSections(ctx, log, storage))
+	// Прежние маршруты без версии, до перехода фронтенда на /api/v1
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Deprecated(cfg.LegacyAPI.DeprecatedAt, cfg.LegacyAPI.Sunset, "/api/v1", log))
+		legacyAPI(r, h)
 	})
 
 	log.Info("starting server", slog.String("address", cfg.HTTPServer.Address))
@@ -167,15 +312,81 @@ func main() {
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Info("shutdown signal received")
+	case err := <-serverErr:
 		log.Error("error starting server", sl.Err(err))
+		exitCode = 1
 	}
 
-	log.Error("server stopped")
+	// Сначала /readyz начинает отвечать 503, чтобы балансировщик перестал слать запросы
+	draining.Store(true)
+	time.Sleep(cfg.HTTPServer.ShutdownDelay)
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain http connections", sl.Err(err))
+	}
+
+	stopBackground()
+	if err := importManager.Wait(shutdownCtx); err != nil {
+		log.Warn("import jobs did not finish before shutdown", sl.Err(err))
+	}
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+	}
+	if ssoClient != nil {
+		if err := ssoClient.Close(); err != nil {
+			log.Error("failed to close sso connection", sl.Err(err))
+		}
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
+
+	log.Info("server stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
 
-func setupLogger(env string, logger string) *slog.Logger {
+// connectStorage подключается к Postgres, повторяя попытки до истечения wait:
+// в docker compose и Kubernetes база может подняться позже сервиса
+func connectStorage(ctx context.Context, log *slog.Logger, storagePath string, blobs blob.BlobStore, wait time.Duration) (*postgresql.Storage, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	backoff := 500 * time.Millisecond
+	for {
+		storage, err := postgresql.New(storagePath, blobs)
+		if err == nil {
+			return storage, nil
+		}
+
+		log.Warn("postgres is not available yet, retrying", slog.Duration("retry_in", backoff), sl.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
+
+func setupLogger(env string, logger string, redaction config.LogRedaction) *slog.Logger {
 	var log *slog.Logger
 	switch env {
 	case envLocal:
@@ -199,7 +410,12 @@ func setupLogger(env string, logger string) *slog.Logger {
 	}
 
 	if log != nil {
-		return log
+		handler := log.Handler()
+		if !redaction.Disabled {
+			handler = redact.NewHandler(handler, redaction.Keys)
+		}
+		// trace_id и span_id в записях, сделанных с контекстом запроса
+		return slog.New(tracing.LogHandler(handler))
 	}
 
 	stdlog.Fatal("can not setup logger")
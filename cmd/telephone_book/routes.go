@@ -0,0 +1,271 @@
+package main
+
+import (
+	"net/http"
+	"telephone-book/internal/http_server/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// apiHandlers обработчики API; создаются один раз и подключаются и в /api/v1, и в устаревшие маршруты
+type apiHandlers struct {
+	searchLimit func(http.Handler) http.Handler
+	photoLimit  func(http.Handler) http.Handler
+
+	// Только пользователь с токеном или сервис с ключом API: выгрузки справочника целиком
+	authenticated func(http.Handler) http.Handler
+
+	// Сроки обработки по группам маршрутов (http_server.deadlines)
+	deadline       func(http.Handler) http.Handler
+	searchDeadline func(http.Handler) http.Handler
+	photoDeadline  func(http.Handler) http.Handler
+	importDeadline func(http.Handler) http.Handler
+	exportDeadline func(http.Handler) http.Handler
+
+	login          http.HandlerFunc
+	register       http.HandlerFunc
+	setup          http.HandlerFunc
+	checkRole      http.HandlerFunc
+	userInfo       http.HandlerFunc
+	logout         http.HandlerFunc
+	revokeSessions http.HandlerFunc
+
+	emergency         http.HandlerFunc
+	search            http.HandlerFunc
+	birthdaysToday    http.HandlerFunc
+	birthdaysTomorrow http.HandlerFunc
+
+	listWorkers           http.HandlerFunc
+	createWorker          http.HandlerFunc
+	createWorkerWithPhoto http.HandlerFunc
+	getWorker             http.HandlerFunc
+	updateWorker          http.HandlerFunc
+	deleteWorker          http.HandlerFunc
+	workerVCard           http.HandlerFunc
+
+	getPhoto     http.HandlerFunc
+	getPhotoByID http.HandlerFunc
+	uploadPhoto  http.HandlerFunc
+	updatePhoto  http.HandlerFunc
+	deletePhoto  http.HandlerFunc
+	cropPhoto    http.HandlerFunc
+	importPhotos http.HandlerFunc
+	pendingList  http.HandlerFunc
+	pendingPhoto http.HandlerFunc
+	approvePhoto http.HandlerFunc
+	rejectPhoto  http.HandlerFunc
+
+	importWorkers http.HandlerFunc
+	importVCard   http.HandlerFunc
+	importStatus  http.HandlerFunc
+	importCancel  http.HandlerFunc
+	importReport  http.HandlerFunc
+
+	exportVCard http.HandlerFunc
+	exportPDF   http.HandlerFunc
+
+	listDepartments    http.HandlerFunc
+	createDepartment   http.HandlerFunc
+	updateDepartment   http.HandlerFunc
+	deleteDepartment   http.HandlerFunc
+	departmentSections http.HandlerFunc
+
+	listAPIKeys  http.HandlerFunc
+	createAPIKey http.HandlerFunc
+	revokeAPIKey http.HandlerFunc
+
+	listPermissions  http.HandlerFunc
+	grantPermission  http.HandlerFunc
+	revokePermission http.HandlerFunc
+}
+
+// apiV1 маршруты /api/v1: ресурсы во множественном числе, объект — в пути, чтение — GET
+func apiV1(r chi.Router, h apiHandlers) {
+	// SSO
+	r.Route("/auth", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Post("/login", h.login)
+		r.Post("/register", h.register)
+		r.Post("/setup", h.setup)
+		r.Get("/check-role", h.checkRole)
+		r.Get("/user-info", h.userInfo)
+		r.Post("/logout", h.logout)
+		r.Post("/sessions/revoke", h.revokeSessions)
+	})
+
+	r.With(h.deadline).Get("/emergency-services", h.emergency)
+	r.With(h.searchLimit, h.searchDeadline).Get("/search", h.search)
+
+	r.Route("/birthdays", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/today", h.birthdaysToday)
+		r.Get("/tomorrow", h.birthdaysTomorrow)
+	})
+
+	r.Route("/workers", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(h.deadline)
+			r.Get("/", h.listWorkers)
+			r.Post("/", h.createWorker)
+			r.Get("/{id:[0-9]+}", h.workerVCard) // /workers/{id}.vcf
+			r.Get("/{email}", h.getWorker)
+			r.Put("/{email}", h.updateWorker)
+			r.Delete("/{email}", h.deleteWorker)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(h.photoLimit, h.photoDeadline)
+			r.Post("/with-photo", h.createWorkerWithPhoto)
+			r.Get("/{id:[0-9]+}/photo", h.getPhotoByID) // ?size=64|256|512
+			r.Get("/{email}/photo", h.getPhoto)
+			r.Post("/{email}/photo", h.uploadPhoto)
+			r.Put("/{email}/photo", h.updatePhoto)
+			r.Delete("/{email}/photo", h.deletePhoto)
+			r.Put("/{email}/photo/crop", h.cropPhoto)
+		})
+	})
+
+	r.Route("/photos", func(r chi.Router) {
+		r.With(h.photoLimit, h.photoDeadline).Post("/import", h.importPhotos)
+		r.With(h.deadline).Get("/pending", h.pendingList)
+		r.With(h.deadline).Get("/pending/{id:[0-9]+}", h.pendingPhoto)
+		r.With(h.photoDeadline).Post("/pending/{id:[0-9]+}/approve", h.approvePhoto) // строит миниатюры
+		r.With(h.deadline).Post("/pending/{id:[0-9]+}/reject", h.rejectPhoto)
+	})
+
+	r.Route("/imports", func(r chi.Router) {
+		r.With(h.importDeadline).Post("/", h.importWorkers)
+		r.With(h.importDeadline).Post("/vcard", h.importVCard)
+		r.With(h.deadline).Get("/{id}", h.importStatus)
+		r.With(h.deadline).Delete("/{id}", h.importCancel)
+		r.With(h.deadline).Get("/{id}/report", h.importReport)
+	})
+
+	r.Route("/exports", func(r chi.Router) {
+		r.Use(h.exportDeadline)
+		r.With(h.authenticated).Get("/vcard", h.exportVCard)
+		r.Get("/pdf", h.exportPDF)
+	})
+
+	r.Route("/departments", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listDepartments)
+		r.Post("/", h.createDepartment)
+		r.Put("/{department}", h.updateDepartment)
+		r.Delete("/{department}", h.deleteDepartment)
+		r.Get("/{department}/sections", h.departmentSections)
+	})
+
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listAPIKeys)
+		r.Post("/", h.createAPIKey)
+		r.Delete("/{id:[0-9]+}", h.revokeAPIKey)
+	})
+
+	r.Route("/permissions", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listPermissions)
+		r.Post("/", h.grantPermission)
+		r.Delete("/{id:[0-9]+}", h.revokePermission)
+	})
+}
+
+// legacyAPI прежние маршруты без версии. Работают через те же обработчики, пока фронтенд
+// не перейдёт на /api/v1; адрес объекта из параметров запроса переносится в путь
+func legacyAPI(r chi.Router, h apiHandlers) {
+	// SSO
+	r.Route("/auth", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Post("/login", h.login)
+		r.Post("/register", h.register)
+		r.Post("/setup", h.setup)
+		r.Get("/check-role", h.checkRole)
+		r.Get("/user-info", h.userInfo)
+		r.Post("/logout", h.logout)
+		r.Post("/sessions/revoke", h.revokeSessions)
+	})
+
+	// Срочные службы
+	r.With(h.deadline).Get("/emergency", h.emergency)
+
+	// Поиск
+	r.With(h.searchLimit, h.searchDeadline).Get("/search", h.search)
+
+	//Др сегодня и завтра
+	r.Route("/birthday", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/today", h.birthdaysToday)
+		r.Get("/tomorrow", h.birthdaysTomorrow)
+	})
+
+	//Работники
+	r.Route("/workers", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(h.deadline)
+			r.Post("/", h.createWorker)
+			r.Get("/photos/pending", h.pendingList)
+			r.Get("/photos/pending/{id:[0-9]+}", h.pendingPhoto)
+			r.Post("/photos/pending/{id:[0-9]+}/reject", h.rejectPhoto)
+			r.Get("/{id:[0-9]+}", h.workerVCard) // /workers/{id}.vcf
+			r.Get("/{email}", h.getWorker)
+			r.Put("/", h.updateWorker) // старый email в поле old_email
+			r.With(middleware.QueryToURLParam("email")).Delete("/", h.deleteWorker)
+			r.Post("/all", h.listWorkers)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(h.photoLimit, h.photoDeadline)
+			r.Post("/with-photo", h.createWorkerWithPhoto)
+			r.Post("/photos/import", h.importPhotos)
+			r.Get("/{id:[0-9]+}/photo", h.getPhotoByID) // ?size=64|256|512
+			r.Get("/{email}/photo", h.getPhoto)
+			r.Post("/{email}/photo", h.uploadPhoto)
+			r.Put("/{email}/photo", h.updatePhoto)
+			r.Delete("/{email}/photo", h.deletePhoto)
+			r.Put("/{email}/photo/crop", h.cropPhoto)
+		})
+		r.With(h.photoDeadline).Post("/photos/pending/{id:[0-9]+}/approve", h.approvePhoto)
+		r.With(h.importDeadline).Post("/import", h.importWorkers)
+		r.With(h.importDeadline).Post("/import/vcard", h.importVCard)
+	})
+
+	// Задачи импорта
+	r.Route("/imports", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/{id}", h.importStatus)
+		r.Delete("/{id}", h.importCancel)
+		r.Get("/{id}/report", h.importReport)
+	})
+
+	// Экспорт
+	r.Route("/export", func(r chi.Router) {
+		r.Use(h.exportDeadline)
+		r.With(h.authenticated).Get("/vcard", h.exportVCard)
+		r.Get("/pdf", h.exportPDF)
+	})
+
+	// Отделы
+	r.Route("/departments", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listDepartments)
+		r.Post("/", h.createDepartment)
+		r.With(middleware.QueryToURLParam("department")).Put("/", h.updateDepartment)
+		r.With(middleware.QueryToURLParam("department")).Delete("/", h.deleteDepartment)
+		r.Get("/{department}", h.departmentSections)
+	})
+
+	// Ключи API сервисов
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listAPIKeys)
+		r.Post("/", h.createAPIKey)
+		r.Delete("/{id:[0-9]+}", h.revokeAPIKey)
+	})
+
+	// Права на институты и отделы
+	r.Route("/permissions", func(r chi.Router) {
+		r.Use(h.deadline)
+		r.Get("/", h.listPermissions)
+		r.Post("/", h.grantPermission)
+		r.Delete("/{id:[0-9]+}", h.revokePermission)
+	})
+}
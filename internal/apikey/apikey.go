@@ -0,0 +1,138 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/storage"
+	"time"
+)
+
+// keyPrefix начало каждого ключа: по нему ключ легко найти в логах и конфигах сервисов
+const keyPrefix = "tb_"
+
+var (
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrInvalidAccess = errors.New("invalid api key access")
+)
+
+type Storage interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// Keys выпускает и проверяет ключи API. Время последнего использования пишется
+// в БД не чаще touchInterval на ключ, чтобы каждый запрос сервиса не был записью.
+type Keys struct {
+	log           *slog.Logger
+	storage       Storage
+	touchInterval time.Duration
+
+	mu      sync.Mutex
+	touched map[int64]time.Time
+}
+
+func New(log *slog.Logger, storage Storage, touchInterval time.Duration) *Keys {
+	return &Keys{
+		log:           log,
+		storage:       storage,
+		touchInterval: touchInterval,
+		touched:       make(map[int64]time.Time),
+	}
+}
+
+// Create выпускает ключ; возвращённую строку ключа больше нигде получить нельзя
+func (k *Keys) Create(ctx context.Context, name string, access string, institutes []string, createdBy int64) (string, models.APIKey, error) {
+	const op = "apikey.Create"
+
+	if access != models.APIKeyRead && access != models.APIKeyReadWrite {
+		return "", models.APIKey{}, ErrInvalidAccess
+	}
+
+	canonical := make([]string, 0, len(institutes))
+	for _, institute := range institutes {
+		schema, err := storage.Schema(institute)
+		if err != nil {
+			return "", models.APIKey{}, fmt.Errorf("%s: %q: %w", op, institute, err)
+		}
+		canonical = append(canonical, schema)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	raw := keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key, err := k.storage.CreateAPIKey(ctx, models.APIKey{
+		Name:       name,
+		Prefix:     raw[:len(keyPrefix)+6],
+		Access:     access,
+		Institutes: canonical,
+		CreatedBy:  createdBy,
+	}, Hash(raw))
+	if err != nil {
+		return "", models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return raw, key, nil
+}
+
+// Authenticate находит действующий ключ по его строке
+func (k *Keys) Authenticate(ctx context.Context, raw string) (models.APIKey, error) {
+	const op = "apikey.Authenticate"
+
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return models.APIKey{}, ErrInvalidKey
+	}
+
+	key, err := k.storage.GetAPIKeyByHash(ctx, Hash(raw))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return models.APIKey{}, ErrInvalidKey
+		}
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k.touch(ctx, key.ID)
+
+	return key, nil
+}
+
+// touch обновляет время использования в фоне, не задерживая запрос
+func (k *Keys) touch(ctx context.Context, id int64) {
+	now := time.Now()
+
+	k.mu.Lock()
+	if last, ok := k.touched[id]; ok && now.Sub(last) < k.touchInterval {
+		k.mu.Unlock()
+		return
+	}
+	k.touched[id] = now
+	k.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := k.storage.TouchAPIKey(ctx, id, now); err != nil {
+			k.log.Warn("failed to save api key last use", slog.Int64("api_key_id", id), sl.Err(err))
+		}
+	}()
+}
+
+// Hash sha256 ключа в hex: в БД хранится только он
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
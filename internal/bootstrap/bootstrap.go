@@ -0,0 +1,179 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	sso "telephone-book/internal/clients/sso/grpc"
+	"telephone-book/internal/config"
+	"telephone-book/internal/lib/logger/sl"
+	"time"
+)
+
+// Способы создания первого администратора, сохраняются в БД
+const (
+	MethodConfig     = "config"
+	MethodSetupToken = "setup_token"
+	MethodCLI        = "cli"
+)
+
+// Роль администратора в SSO
+const adminRole = "admin"
+
+var (
+	ErrAlreadyBootstrapped = errors.New("bootstrap already completed")
+	ErrSetupDisabled       = errors.New("setup token is not configured")
+	ErrInvalidSetupToken   = errors.New("invalid setup token")
+	ErrUserExists          = errors.New("user already exists in sso")
+)
+
+type Registrar interface {
+	Register(ctx context.Context, email string, password string, role string) (int64, error)
+}
+
+type Storage interface {
+	IsBootstrapped(ctx context.Context) (bool, error)
+	CompleteBootstrap(ctx context.Context, email string, userID int64, method string) error
+}
+
+// Bootstrapper создаёт первого администратора ровно один раз; отметка об этом
+// хранится в БД, поэтому перезапуски и остальные экземпляры сервиса SSO не трогают
+type Bootstrapper struct {
+	log     *slog.Logger
+	sso     Registrar
+	storage Storage
+	cfg     config.Bootstrap
+
+	mu sync.Mutex
+}
+
+func New(log *slog.Logger, sso Registrar, storage Storage, cfg config.Bootstrap) *Bootstrapper {
+	return &Bootstrapper{
+		log:     log,
+		sso:     sso,
+		storage: storage,
+		cfg:     cfg,
+	}
+}
+
+// Run при запуске создаёт администратора из конфига, если первый запуск ещё не выполнен
+func (b *Bootstrapper) Run(ctx context.Context) error {
+	const op = "bootstrap.Run"
+
+	done, err := b.storage.IsBootstrapped(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if done {
+		return nil
+	}
+
+	if b.cfg.AdminEmail == "" || b.cfg.AdminPassword == "" {
+		if b.cfg.SetupTokenHash != "" {
+			b.log.Info("no administrator yet, waiting for POST /auth/setup")
+		} else {
+			b.log.Warn("no administrator yet: set bootstrap.admin_email and BOOTSTRAP_ADMIN_PASSWORD, a setup token or run `telephone_book admin create`")
+		}
+		return nil
+	}
+
+	// SSO может подниматься одновременно с сервисом
+	const attempts = 5
+	for i := 1; ; i++ {
+		_, err = b.CreateAdmin(ctx, b.cfg.AdminEmail, b.cfg.AdminPassword, MethodConfig)
+		if errors.Is(err, ErrUserExists) {
+			// Администратор из конфига уже заведён в SSO вручную: его роль отсюда не проверить,
+			// но и создавать его заново не нужно
+			b.log.Warn("bootstrap administrator already exists in sso", slog.String("email", b.cfg.AdminEmail))
+			err = b.storage.CompleteBootstrap(ctx, b.cfg.AdminEmail, 0, MethodConfig)
+			break
+		}
+		if err == nil || i == attempts {
+			break
+		}
+
+		b.log.Warn("failed to create administrator, retrying", sl.Err(err), slog.Int("attempt", i))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(5 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Setup создаёт первого администратора по одноразовому токену из конфига
+func (b *Bootstrapper) Setup(ctx context.Context, setupToken string, email string, password string) (int64, error) {
+	const op = "bootstrap.Setup"
+
+	if b.cfg.SetupTokenHash == "" {
+		return 0, ErrSetupDisabled
+	}
+
+	sum := sha256.Sum256([]byte(setupToken))
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(strings.ToLower(b.cfg.SetupTokenHash))) != 1 {
+		return 0, ErrInvalidSetupToken
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	done, err := b.storage.IsBootstrapped(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if done {
+		return 0, ErrAlreadyBootstrapped
+	}
+
+	userID, err := b.register(ctx, email, password, MethodSetupToken)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// CreateAdmin регистрирует администратора в SSO и, если это первый администратор,
+// отмечает первый запуск выполненным. Используется при запуске и командой admin create.
+func (b *Bootstrapper) CreateAdmin(ctx context.Context, email string, password string, method string) (int64, error) {
+	const op = "bootstrap.CreateAdmin"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	userID, err := b.register(ctx, email, password, method)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (b *Bootstrapper) register(ctx context.Context, email string, password string, method string) (int64, error) {
+	userID, err := b.sso.Register(ctx, email, password, adminRole)
+	if err != nil {
+		if sso.AlreadyExists(err) {
+			return 0, ErrUserExists
+		}
+		return 0, err
+	}
+
+	b.log.Info("administrator created", slog.String("email", email), slog.Int64("user_id", userID), slog.String("method", method))
+
+	if err := b.storage.CompleteBootstrap(ctx, email, userID, method); err != nil {
+		return userID, err
+	}
+
+	return userID, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"telephone-book/internal/metrics"
+	"telephone-book/internal/tracing"
 	"time"
 
 	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
@@ -11,11 +13,14 @@ import (
 	ssov1 "github.com/krawwwwy/rosatomprotos"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type Client struct {
 	api ssov1.AuthClient
+	cc  *grpc.ClientConn
 	log *slog.Logger
 }
 
@@ -40,6 +45,8 @@ func New(ctx context.Context,
 	cc, err := grpc.DialContext(ctx, addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			metrics.SSOInterceptor(),
 			grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
 			grpcretry.UnaryClientInterceptor(retryOpts...),
 		))
@@ -50,9 +57,29 @@ func New(ctx context.Context,
 
 	return &Client{
 		api: ssov1.NewAuthClient(cc),
+		cc:  cc,
 	}, nil
 }
 
+// Ready проверяет состояние соединения с SSO (для /readyz). Простаивающее соединение
+// считается готовым и переподключается; ошибкой считаются сбой и закрытое соединение
+func (c *Client) Ready() error {
+	switch state := c.cc.GetState(); state {
+	case connectivity.Ready:
+		return nil
+	case connectivity.Idle:
+		c.cc.Connect()
+		return nil
+	default:
+		return fmt.Errorf("sso connection is %s", state)
+	}
+}
+
+// Close закрывает соединение с SSO
+func (c *Client) Close() error {
+	return c.cc.Close()
+}
+
 func (c *Client) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "grpc.IsAdmin"
 
@@ -87,6 +114,31 @@ func (c *Client) Register(ctx context.Context, email string, password string, ro
 
 }
 
+// Unavailable ошибка из-за недоступности SSO, а не из-за неверных данных запроса
+func Unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// InvalidCredentials SSO отклонил вход из-за неверной почты или пароля
+func InvalidCredentials(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.Unauthenticated, codes.PermissionDenied:
+		return true
+	default:
+		return false
+	}
+}
+
+// AlreadyExists пользователь с такой почтой уже зарегистрирован в SSO
+func AlreadyExists(err error) bool {
+	return status.Code(err) == codes.AlreadyExists
+}
+
 func (c *Client) Login(ctx context.Context, email string, password string, appID int32) (string, error) {
 	const op = "grpc.Login"
 
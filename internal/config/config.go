@@ -1,25 +1,226 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
 	"time"
 
+	"telephone-book/internal/storage"
+
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env         string        `yaml:"env" env-default:"prod"`
-	StoragePath string        `yaml:"storage_path" env-required:"true"`
-	Logger      string        `yaml:"logger" env-default:"default"`
-	HTTPServer  HTTPServer    `yaml:"http_server"`
-	Clients     ClientsConfig `yaml:"clients"`
+	Env         string `yaml:"env" env-default:"prod"`
+	StoragePath string `yaml:"storage_path" env-required:"true"`
+	// StorageWait сколько при запуске ждать, пока Postgres начнёт принимать соединения
+	StorageWait  time.Duration `yaml:"storage_wait" env-default:"1m"`
+	Logger       string        `yaml:"logger" env-default:"default"`
+	LogRedaction LogRedaction  `yaml:"log_redaction"`
+	HTTPServer   HTTPServer    `yaml:"http_server"`
+	Clients      ClientsConfig `yaml:"clients"`
+	PDF          PDF           `yaml:"pdf"`
+	Import       Import        `yaml:"import"`
+	Blob         Blob          `yaml:"blob"`
+	Moderation   Moderation    `yaml:"moderation"`
+	Auth         Auth          `yaml:"auth"`
+	Bootstrap    Bootstrap     `yaml:"bootstrap"`
+	RateLimit    RateLimit     `yaml:"rate_limit"`
+	LegacyAPI    LegacyAPI     `yaml:"legacy_api"`
+	Tracing      Tracing       `yaml:"tracing"`
 }
 
 type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"0.0.0.0:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Deadlines   Deadlines     `yaml:"deadlines"`
+	// ShutdownDelay пауза после SIGTERM, пока /readyz отвечает 503 и балансировщик убирает экземпляр
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env-default:"0s"`
+	// ShutdownTimeout сколько ждать завершения текущих запросов и задач импорта при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+}
+
+// Deadlines сроки обработки запросов по группам маршрутов; по истечении срока
+// отменяется контекст запроса и прерываются его запросы к БД
+type Deadlines struct {
+	Default time.Duration `yaml:"default" env-default:"3s"`
+	Search  time.Duration `yaml:"search" env-default:"2s"`
+	// Photos загрузка и обработка фотографий, в том числе архивом
+	Photos time.Duration `yaml:"photos" env-default:"30s"`
+	// Import приём файла импорта; сам импорт идёт в фоне и сроком не ограничен
+	Import time.Duration `yaml:"import" env-default:"30s"`
+	// Export выгрузка справочника в vCard и PDF
+	Export time.Duration `yaml:"export" env-default:"60s"`
+}
+
+type PDF struct {
+	FontPath     string `yaml:"font_path" env-default:"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"`
+	BoldFontPath string `yaml:"bold_font_path" env-default:"/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf"`
+}
+
+type Import struct {
+	Workers int `yaml:"workers" env-default:"2"`
+	// QueueSize сколько задач может ждать в очереди всех экземпляров вместе
+	QueueSize int `yaml:"queue_size" env-default:"16"`
+	// Lease срок аренды задачи экземпляром; продлевается каждую треть срока. Задачи
+	// остановленного экземпляра завершаются как оборванные не раньше чем через этот срок
+	Lease time.Duration `yaml:"lease" env-default:"1m"`
+	// PollInterval как часто простаивающие воркеры проверяют очередь на задачи, поставленные
+	// другими экземплярами или оставшиеся после перезапуска
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+}
+
+type Blob struct {
+	// Driver fs или s3
+	Driver string `yaml:"driver" env-default:"fs"`
+	Path   string `yaml:"path" env-default:"./data/blobs"`
+	S3     S3     `yaml:"s3"`
+	// GCInterval как часто удалять фотографии, на которые больше никто не ссылается
+	GCInterval time.Duration `yaml:"gc_interval" env-default:"10m"`
+	// OrphanGrace сколько объект лежит без ссылок, прежде чем его можно удалить;
+	// должно быть больше времени между записью фото и сохранением строки с ним
+	OrphanGrace time.Duration `yaml:"orphan_grace" env-default:"1h"`
+}
+
+type S3 struct {
+	Endpoint  string `yaml:"endpoint" env-default:"localhost:9000"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	Bucket    string `yaml:"bucket" env-default:"telephone-book"`
+	Region    string `yaml:"region"`
+	UseSSL    bool   `yaml:"use_ssl"`
+}
+
+type Moderation struct {
+	// PhotoInstitutes институты, где фото от не-администраторов ждут одобрения
+	PhotoInstitutes []string `yaml:"photo_institutes"`
+}
+
+// PhotoModerationEnabled включена ли проверка фотографий для института.
+// Институт сравнивается по схеме, поэтому "Графит" и "grafit" равнозначны
+func (m Moderation) PhotoModerationEnabled(institute string) bool {
+	schema, err := storage.Schema(institute)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(m.PhotoInstitutes, schema)
+}
+
+// normalize приводит PhotoInstitutes к именам схем; неизвестный институт — ошибка конфигурации
+func (m *Moderation) normalize() error {
+	for i, institute := range m.PhotoInstitutes {
+		schema, err := storage.Schema(institute)
+		if err != nil {
+			return fmt.Errorf("moderation.photo_institutes: %q: %w", institute, err)
+		}
+		m.PhotoInstitutes[i] = schema
+	}
+	return nil
+}
+
+type Auth struct {
+	// AppID приложение SSO, для которого выдаются токены при входе
+	AppID int32 `yaml:"app_id" env-default:"1"`
+	// AppSecret секрет HS256 приложения AppID; удобнее задавать через окружение
+	AppSecret string `yaml:"app_secret" env:"SSO_APP_SECRET"`
+	// Apps ключи проверки токенов остальных приложений, выбираются по claim app_id
+	Apps []AuthApp `yaml:"apps"`
+	// JWKSPath локальный файл JWKS с открытыми ключами RS256/ES256, выбираются по kid
+	JWKSPath string `yaml:"jwks_path"`
+	// JWKSRefresh как часто проверять, не заменён ли файл JWKS
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" env-default:"1m"`
+	// Issuer ожидаемый claim iss; пустой — не проверяется
+	Issuer string `yaml:"issuer"`
+	// Audience ожидаемое значение в claim aud; пустое — не проверяется
+	Audience string `yaml:"audience"`
+	// ClockSkew допустимое расхождение часов с SSO при проверке exp, nbf и iat
+	ClockSkew time.Duration `yaml:"clock_skew" env-default:"30s"`
+	// TokenTTL время жизни токенов SSO; по нему считается время выдачи токенов без iat
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	// RevocationRefresh как часто перечитывать список отозванных токенов из БД
+	RevocationRefresh time.Duration `yaml:"revocation_refresh" env-default:"30s"`
+	// RoleCacheTTL сколько доверять роли, полученной из SSO
+	RoleCacheTTL time.Duration `yaml:"role_cache_ttl" env-default:"1m"`
+	// RoleCacheSize наибольшее число токенов в кеше ролей
+	RoleCacheSize int `yaml:"role_cache_size" env-default:"10000"`
+	// APIKeyTouchInterval как часто записывать время последнего использования ключа API
+	APIKeyTouchInterval time.Duration `yaml:"api_key_touch_interval" env-default:"1m"`
+}
+
+type RateLimit struct {
+	// TrustProxy брать адрес клиента из X-Real-IP/X-Forwarded-For; включать только за своим прокси
+	TrustProxy bool        `yaml:"trust_proxy"`
+	Login      LoginLimit  `yaml:"login"`
+	Search     ClientLimit `yaml:"search"`
+	Photos     ClientLimit `yaml:"photos"`
+}
+
+// LoginLimit защита входа от перебора паролей. После AccountAttempts (IPAttempts)
+// неудач подряд вход блокируется на BaseLockout, каждая следующая неудача удваивает блокировку
+type LoginLimit struct {
+	// Store memory — счётчики в памяти экземпляра, postgres — общие для всех экземпляров
+	Store           string `yaml:"store" env-default:"memory"`
+	AccountAttempts int    `yaml:"account_attempts" env-default:"5"`
+	IPAttempts      int    `yaml:"ip_attempts" env-default:"20"`
+	// Window через сколько после последней неудачи счётчик начинается заново
+	Window      time.Duration `yaml:"window" env-default:"15m"`
+	BaseLockout time.Duration `yaml:"base_lockout" env-default:"30s"`
+	MaxLockout  time.Duration `yaml:"max_lockout" env-default:"1h"`
+}
+
+// ClientLimit запросов в секунду на клиента (ключ API, пользователя или адрес) и запас на всплеск
+type ClientLimit struct {
+	RPS   float64 `yaml:"rps" env-default:"10"`
+	Burst int     `yaml:"burst" env-default:"20"`
+}
+
+// Bootstrap первый администратор. Создаётся один раз: при запуске из AdminEmail и
+// AdminPassword либо запросом POST /auth/setup с одноразовым токеном
+type Bootstrap struct {
+	AdminEmail string `yaml:"admin_email" env:"BOOTSTRAP_ADMIN_EMAIL"`
+	// AdminPassword задаётся только через окружение и после первого запуска не нужен
+	AdminPassword string `yaml:"-" env:"BOOTSTRAP_ADMIN_PASSWORD"`
+	// SetupTokenHash sha256 одноразового токена в hex; пустой — /auth/setup выключен
+	SetupTokenHash string `yaml:"setup_token_hash" env:"BOOTSTRAP_SETUP_TOKEN_HASH"`
+}
+
+// LegacyAPI маршруты без префикса /api/v1; оставлены, пока фронтенд не перейдёт на новые
+type LegacyAPI struct {
+	// DeprecatedAt с какого дня маршруты считаются устаревшими (заголовок Deprecation)
+	DeprecatedAt time.Time `yaml:"deprecated_at" env-layout:"2006-01-02" env-default:"2026-10-18"`
+	// Sunset когда маршруты планируется убрать (заголовок Sunset)
+	Sunset time.Time `yaml:"sunset" env-layout:"2006-01-02" env-default:"2027-04-01"`
+}
+
+// LogRedaction скрытие секретов и персональных данных в логах, для любого формата логгера
+type LogRedaction struct {
+	// Disabled показывать всё как есть; только для локальной отладки
+	Disabled bool `yaml:"disabled"`
+	// Keys слова в названиях атрибутов, значения которых скрываются (без учёта регистра, "_" и "-")
+	Keys []string `yaml:"keys" env-default:"password,secret,token,authorization,cookie,access_key,photo,birth,phone"`
+}
+
+// Tracing трассировка OpenTelemetry; спаны уходят по OTLP/gRPC в коллектор
+type Tracing struct {
+	// Endpoint адрес коллектора host:port; пустой — спаны не отправляются,
+	// но контекст трассировки из входящих запросов всё равно передаётся дальше
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	// TLS соединение с коллектором по TLS; без него — для коллектора рядом с сервисом
+	TLS bool `yaml:"tls"`
+	// SampleRatio доля новых трасс, которые записываются; решение вызывающего сервиса соблюдается
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"telephone-book"`
+}
+
+type AuthApp struct {
+	ID int32 `yaml:"id"`
+	// Secret секрет HS256
+	Secret string `yaml:"secret"`
+	// PublicKeyPath PEM файл с открытым ключом RS256 или ES256
+	PublicKeyPath string `yaml:"public_key_path"`
 }
 
 type Client struct {
@@ -47,5 +248,9 @@ func MustLoad() *Config {
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		log.Fatalf("error reading config :%s", err)
 	}
+
+	if err := cfg.Moderation.normalize(); err != nil {
+		log.Fatalf("invalid config :%s", err)
+	}
 	return &cfg
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Доступ ключа API
+const (
+	APIKeyRead      = "read"
+	APIKeyReadWrite = "read_write"
+)
+
+// APIKey ключ API сервиса; сам ключ показывается один раз при создании и не хранится
+type APIKey struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Prefix начало ключа, по которому его можно узнать
+	Prefix string `json:"prefix"`
+	// Access read или read_write
+	Access string `json:"access"`
+	// Institutes институты, в которых ключ может изменять данные
+	Institutes []string   `json:"institutes"`
+	CreatedBy  int64      `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ReadOnly ключ только для чтения
+func (k APIKey) ReadOnly() bool {
+	return k.Access != APIKeyReadWrite
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+	ImportJobCancelled = "cancelled"
+)
+
+// ImportJob фоновая задача импорта работников
+type ImportJob struct {
+	ID            int64  `json:"id"`
+	Institute     string `json:"institute"`
+	Format        string `json:"format"`
+	Status        string `json:"status"`
+	Progress      int    `json:"progress"`
+	TotalRows     int    `json:"total_rows"`
+	ProcessedRows int    `json:"processed_rows"`
+	ImportedRows  int    `json:"imported_rows"`
+	FailedRows    int    `json:"failed_rows"`
+	// Что делать со строками, у которых отдел или секция не существует: keep, create, reject
+	MissingOrgUnits string `json:"missing_org_units"`
+	// Отделы и секции, созданные при импорте
+	CreatedOrgUnits []OrgUnit        `json:"created_org_units"`
+	Error           string           `json:"error,omitempty"`
+	Errors          []ImportRowError `json:"-"`
+	CreatedBy       int64            `json:"created_by,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+	// Экземпляр сервиса, который выполняет задачу
+	Owner string `json:"-"`
+	// Отмену запросили из экземпляра, который задачу не выполняет
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+	// Ключ файла импорта в BlobStore; файл удаляется после завершения задачи
+	PayloadKey string `json:"-"`
+}
+
+// Finished задача больше не будет выполняться
+func (j ImportJob) Finished() bool {
+	return j.Status == ImportJobCompleted || j.Status == ImportJobFailed || j.Status == ImportJobCancelled
+}
+
+// OrgUnit отдел или секция отдела
+type OrgUnit struct {
+	Department string `json:"department"`
+	Section    string `json:"section,omitempty"`
+}
+
+// ImportRowError строка файла, которую не удалось импортировать
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempts неудачные попытки входа по ключу: ip:<адрес> или account:<email>
+type LoginAttempts struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil до этого момента вход по ключу запрещён
+	LockedUntil time.Time
+}
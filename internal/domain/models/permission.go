@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Grant право пользователя SSO на институт или отдел
+type Grant struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+	// Role admin или editor
+	Role      string `json:"role"`
+	Institute string `json:"institute"`
+	// Department пустой — право на весь институт
+	Department string    `json:"department,omitempty"`
+	CreatedBy  int64     `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// CropRect область кадрирования фотографии в пикселях оригинала
+type CropRect struct {
+	X      int `json:"x" validate:"min=0"`
+	Y      int `json:"y" validate:"min=0"`
+	Width  int `json:"width" validate:"required,min=1"`
+	Height int `json:"height" validate:"required,min=1"`
+}
+
+// PendingPhoto фотография, ожидающая одобрения администратором
+type PendingPhoto struct {
+	ID          int       `json:"id"`
+	WorkerID    int       `json:"worker_id"`
+	Email       string    `json:"email"`
+	Surname     string    `json:"surname"`
+	Name        string    `json:"name"`
+	MiddleName  string    `json:"middle_name"`
+	Crop        *CropRect `json:"crop,omitempty"`
+	SubmittedBy int64     `json:"submitted_by,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RevokedToken отдельный отозванный токен, хранится до истечения его срока
+type RevokedToken struct {
+	// Hash sha256 токена в hex; сам токен не хранится
+	Hash      string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// RevokedSession все токены пользователя, выданные раньше RevokedBefore, недействительны
+type RevokedSession struct {
+	UserID        int64
+	RevokedBefore time.Time
+}
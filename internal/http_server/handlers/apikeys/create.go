@@ -0,0 +1,128 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/apikey"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type CreateRequest struct {
+	// Название сервиса. Пример: "АТС"
+	Name string `json:"name" validate:"required"`
+	// Доступ: read или read_write
+	Access string `json:"access" validate:"required"`
+	// Институты, в которых ключ может изменять данные
+	Institutes []string `json:"institutes"`
+}
+
+type CreateResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Ключ для заголовка X-API-Key; показывается только один раз
+	Key string `json:"key"`
+	// Данные ключа
+	APIKey models.APIKey `json:"api_key"`
+}
+
+type KeyCreator interface {
+	Create(ctx context.Context, name string, access string, institutes []string, createdBy int64) (string, models.APIKey, error)
+}
+
+// Create выпускает ключ API для сервиса
+// @Summary Выпустить ключ API
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body CreateRequest true "Ключ"
+// @Success 200 {object} CreateResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /api-keys [post]
+func Create(log *slog.Logger, keyCreator KeyCreator, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikeys.create.Create"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionAPIKeyManage, policy.Scope{}) {
+			return
+		}
+
+		var req CreateRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			msg := "failed to decode request body"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(ctx, "invalid request", sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		if req.Access == models.APIKeyReadWrite && len(req.Institutes) == 0 {
+			msg := "read_write key requires at least one institute"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		adminID, _ := middleware.GetUserID(r.Context())
+		raw, key, err := keyCreator.Create(ctx, req.Name, req.Access, req.Institutes, adminID)
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalidAccess) {
+				msg := "invalid access: expected read or read_write"
+				log.ErrorContext(ctx, msg, slog.String("access", req.Access))
+				resp.BadRequest(w, r, msg)
+				return
+			}
+			if errors.Is(err, storage.ErrSchemaNotExist) {
+				msg := "institute not found"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to create api key"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "api key created",
+			slog.Int64("api_key_id", key.ID),
+			slog.String("name", key.Name),
+			slog.String("access", key.Access),
+			slog.Any("institutes", key.Institutes),
+			slog.Int64("created_by", adminID),
+		)
+
+		render.JSON(w, r, CreateResponse{
+			Status: resp.OK().Status,
+			Key:    raw,
+			APIKey: key,
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package apikeys
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type KeysGetter interface {
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+}
+
+type ListResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Ключи API, включая отозванные; сами ключи не возвращаются
+	Keys []models.APIKey `json:"keys"`
+}
+
+// List возвращает ключи API сервисов
+// @Summary Ключи API
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} ListResponse
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /api-keys [get]
+func List(log *slog.Logger, keysGetter KeysGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikeys.list.List"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionAPIKeyManage, policy.Scope{}) {
+			return
+		}
+
+		keys, err := keysGetter.GetAPIKeys(ctx)
+		if err != nil {
+			msg := "failed to get api keys"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		render.JSON(w, r, ListResponse{
+			Status: resp.OK().Status,
+			Keys:   keys,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type KeyRevoker interface {
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// Revoke отзывает ключ API; запросы с ним сразу перестают проходить
+// @Summary Отозвать ключ API
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /api-keys/{id} [delete]
+func Revoke(log *slog.Logger, keyRevoker KeyRevoker, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikeys.revoke.Revoke"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionAPIKeyManage, policy.Scope{}) {
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			msg := "invalid api key id"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		if err := keyRevoker.RevokeAPIKey(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrAPIKeyNotFound) {
+				msg := "api key not found"
+				log.InfoContext(ctx, msg, slog.Int64("api_key_id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to revoke api key"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		adminID, _ := middleware.GetUserID(r.Context())
+		log.InfoContext(ctx, "api key revoked", slog.Int64("api_key_id", id), slog.Int64("revoked_by", adminID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
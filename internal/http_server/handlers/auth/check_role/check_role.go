@@ -1,7 +1,6 @@
 package check_role
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
 
@@ -23,13 +22,13 @@ type CheckRoleResponse struct {
 // @Tags auth
 // @Produce json
 // @Success 200 {object} CheckRoleResponse
-// @Failure 401 {object} response.Response
+// @Failure 401 {object} response.Problem
 // @Router /auth/check-role [get]
-func CheckRole(ctx context.Context, log *slog.Logger) http.HandlerFunc {
+func CheckRole(log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.auth.check_role.CheckRole"
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
@@ -48,7 +47,7 @@ func CheckRole(ctx context.Context, log *slog.Logger) http.HandlerFunc {
 			roleString = "guest"
 		}
 
-		log.Info("role checked", slog.String("role", roleString))
+		log.InfoContext(r.Context(), "role checked", slog.String("role", roleString))
 
 		render.JSON(w, r, CheckRoleResponse{
 			Status: resp.OK().Status,
@@ -2,11 +2,15 @@ package login
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"telephone-book/internal/clients/sso/grpc"
 	"telephone-book/internal/lib/logger/sl"
+	"time"
 
+	middleware "telephone-book/internal/http_server/middleware"
 	resp "telephone-book/internal/lib/response"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
@@ -29,7 +33,12 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
-const AppID = 1
+// LoginGuard ограничивает перебор паролей по адресу клиента и учётной записи
+type LoginGuard interface {
+	Check(ctx context.Context, ip string, email string) (time.Duration, error)
+	Failure(ctx context.Context, ip string, email string) error
+	Success(ctx context.Context, email string) error
+}
 
 // New авторизует пользователя
 // @Summary Вход пользователя
@@ -38,31 +47,64 @@ const AppID = 1
 // @Produce json
 // @Param login body LoginRequest true "Данные для входа"
 // @Success 200 {object} login.LoginResponse
-// @Failure 400 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 429 {object} response.Problem
+// @Failure 503 {object} response.Problem
 // @Router /auth/login [post]
-func New(ctx context.Context, ssoClient *grpc.Client, log *slog.Logger) http.HandlerFunc {
+func New(ssoClient *grpc.Client, log *slog.Logger, appID int32, guard LoginGuard) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.auth.login.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
 		var req LoginRequest
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
-			log.Error("failed to decode login request", sl.Err(err))
-			render.JSON(w, r, resp.Error("invalid request"))
+			log.ErrorContext(r.Context(), "failed to decode login request", sl.Err(err))
+			resp.BodyError(w, r, err, "invalid request")
+			return
+		}
+
+		ip := middleware.ClientIP(r)
+
+		// Если хранилище счётчиков недоступно, вход не блокируем
+		wait, err := guard.Check(r.Context(), ip, req.Email)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to check login attempts", sl.Err(err))
+		}
+		if wait > 0 {
+			log.WarnContext(r.Context(), "login is locked", slog.String("ip", ip), slog.Duration("retry_after", wait))
+			middleware.TooManyRequests(w, r, wait, fmt.Sprintf("too many login attempts, retry in %d seconds", int(math.Ceil(wait.Seconds()))))
 			return
 		}
-		_, _ = ssoClient.Register(context.Background(), "krawy@krawy.ru", "krawy", "admin")
-		token, err := ssoClient.Login(r.Context(), req.Email, req.Password, AppID)
+
+		token, err := ssoClient.Login(r.Context(), req.Email, req.Password, appID)
 		if err != nil {
-			log.Error("failed to login", sl.Err(err))
-			render.JSON(w, r, resp.Error("login failed"))
+			log.ErrorContext(r.Context(), "failed to login", sl.Err(err))
+			// Недоступность SSO не считается неудачной попыткой
+			if !grpc.Unavailable(err) {
+				if err := guard.Failure(r.Context(), ip, req.Email); err != nil {
+					log.ErrorContext(r.Context(), "failed to record login failure", sl.Err(err))
+				}
+			}
+			switch {
+			case grpc.Unavailable(err):
+				resp.Unavailable(w, r, "authentication service is unavailable")
+			case grpc.InvalidCredentials(err):
+				resp.Unauthorized(w, r, "invalid email or password")
+			default:
+				resp.Internal(w, r, "login failed")
+			}
 			return
 		}
-		log.Info("user logged in successfully", slog.String("email", req.Email))
+
+		if err := guard.Success(r.Context(), req.Email); err != nil {
+			log.ErrorContext(r.Context(), "failed to reset login attempts", sl.Err(err))
+		}
+		log.InfoContext(r.Context(), "user logged in successfully", slog.String("email", req.Email))
 		responseOK(w, r, token)
 	}
 }
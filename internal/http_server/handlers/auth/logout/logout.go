@@ -0,0 +1,62 @@
+package logout
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/lib/token"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, tokenString string, claims token.Claims) error
+}
+
+type RoleInvalidator interface {
+	Invalidate(userID int64, tokenID string)
+}
+
+// New отзывает токен, с которым пришёл запрос
+// @Summary Выход пользователя
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem
+// @Router /auth/logout [post]
+func New(log *slog.Logger, tokenRevoker TokenRevoker, roles RoleInvalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.logout.New"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		tokenString, claims, ok := middleware.GetToken(r.Context())
+		if !ok {
+			log.WarnContext(ctx, "no valid token in request")
+			resp.Unauthorized(w, r, "unauthorized")
+			return
+		}
+
+		if err := tokenRevoker.RevokeToken(ctx, tokenString, claims); err != nil {
+			msg := "failed to revoke token"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		roles.Invalidate(claims.UserID, claims.TokenID)
+
+		log.InfoContext(ctx, "user logged out", slog.Int64("user_id", claims.UserID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
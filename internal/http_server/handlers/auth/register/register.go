@@ -1,7 +1,6 @@
 package register
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
 	"telephone-book/internal/clients/sso/grpc"
@@ -35,40 +34,50 @@ type RegisterResponse struct {
 // @Produce json
 // @Param register body RegisterRequest true "Данные для регистрации"
 // @Success 200 {object} RegisterResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Router /auth/register [post]
-func New(ctx context.Context, ssoClient *grpc.Client, log *slog.Logger) http.HandlerFunc {
+func New(ssoClient *grpc.Client, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.auth.register.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
 		role := middleware.GetRole(r.Context(), log)
 		if role != middleware.RoleAdmin {
-			log.Error("unauthorized: only admin can register users")
-			render.JSON(w, r, resp.Error("unauthorized"))
+			log.ErrorContext(r.Context(), "unauthorized: only admin can register users")
+			resp.Unauthorized(w, r, "unauthorized")
 			return
 		}
 
 		var req RegisterRequest
 		if err := render.DecodeJSON(r.Body, &req); err != nil {
-			log.Error("failed to decode register request", sl.Err(err))
-			render.JSON(w, r, resp.Error("invalid request"))
+			log.ErrorContext(r.Context(), "failed to decode register request", sl.Err(err))
+			resp.BodyError(w, r, err, "invalid request")
 			return
 		}
 
 		userID, err := ssoClient.Register(r.Context(), req.Email, req.Password, req.Role)
 		if err != nil {
-			log.Error("failed to register user", sl.Err(err))
-			render.JSON(w, r, resp.Error("registration failed"))
+			log.ErrorContext(r.Context(), "failed to register user", sl.Err(err))
+			switch {
+			case grpc.AlreadyExists(err):
+				resp.Conflict(w, r, "user already exists")
+			case grpc.Unavailable(err):
+				resp.Unavailable(w, r, "authentication service is unavailable")
+			case grpc.InvalidCredentials(err):
+				resp.BadRequest(w, r, "invalid email, password or role")
+			default:
+				resp.Internal(w, r, "registration failed")
+			}
 			return
 		}
 
-		log.Info("user registered successfully", slog.Int("user_id", int(userID)))
+		log.InfoContext(r.Context(), "user registered successfully", slog.Int("user_id", int(userID)))
 		responseOK(w, r, userID)
 	}
 }
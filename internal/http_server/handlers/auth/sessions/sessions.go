@@ -0,0 +1,85 @@
+package sessions
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type RevokeRequest struct {
+	// id пользователя в SSO
+	UserID int64 `json:"user_id" validate:"required,min=1"`
+}
+
+type UserRevoker interface {
+	RevokeUser(ctx context.Context, userID int64, revokedBy int64) error
+}
+
+type RoleInvalidator interface {
+	InvalidateUser(userID int64)
+}
+
+// Revoke завершает все сессии пользователя: уже выданные ему токены перестают действовать
+// @Summary Завершить все сессии пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RevokeRequest true "Пользователь"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /auth/sessions/revoke [post]
+func Revoke(log *slog.Logger, userRevoker UserRevoker, roles RoleInvalidator, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.sessions.Revoke"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionSessionRevoke, policy.Scope{}) {
+			return
+		}
+
+		var req RevokeRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			msg := "failed to decode request body"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(ctx, "invalid request", sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		adminID, _ := middleware.GetUserID(r.Context())
+		if err := userRevoker.RevokeUser(ctx, req.UserID, adminID); err != nil {
+			msg := "failed to revoke sessions"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		roles.InvalidateUser(req.UserID)
+
+		log.InfoContext(ctx, "user sessions revoked", slog.Int64("user_id", req.UserID), slog.Int64("revoked_by", adminID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
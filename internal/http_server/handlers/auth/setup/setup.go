@@ -0,0 +1,104 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/bootstrap"
+	"telephone-book/internal/lib/logger/sl"
+
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type SetupRequest struct {
+	// Одноразовый токен, sha256 которого задан в bootstrap.setup_token_hash
+	SetupToken string `json:"setup_token" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required,min=8"`
+}
+
+type SetupResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error  string `json:"error,omitempty"`
+	UserID int64  `json:"user_id"`
+}
+
+type AdminBootstrapper interface {
+	Setup(ctx context.Context, setupToken string, email string, password string) (int64, error)
+}
+
+// New создаёт первого администратора по одноразовому токену; после этого запрос больше не работает
+// @Summary Первый администратор
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param setup body SetupRequest true "Токен и данные администратора"
+// @Success 200 {object} SetupResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Router /auth/setup [post]
+func New(log *slog.Logger, bootstrapper AdminBootstrapper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.setup.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		var req SetupRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.ErrorContext(r.Context(), "failed to decode setup request", sl.Err(err))
+			resp.BodyError(w, r, err, "invalid request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		userID, err := bootstrapper.Setup(r.Context(), req.SetupToken, req.Email, req.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, bootstrap.ErrSetupDisabled):
+				msg := "setup is disabled"
+				log.WarnContext(r.Context(), msg)
+				resp.NotFound(w, r, msg)
+			case errors.Is(err, bootstrap.ErrInvalidSetupToken):
+				msg := "forbidden: invalid setup token"
+				log.WarnContext(r.Context(), msg)
+				resp.Forbidden(w, r, msg)
+			case errors.Is(err, bootstrap.ErrAlreadyBootstrapped):
+				msg := "setup already completed"
+				log.WarnContext(r.Context(), msg)
+				resp.Conflict(w, r, msg)
+			case errors.Is(err, bootstrap.ErrUserExists):
+				msg := "user already exists"
+				log.WarnContext(r.Context(), msg)
+				resp.Conflict(w, r, msg)
+			default:
+				log.ErrorContext(r.Context(), "failed to create administrator", sl.Err(err))
+				resp.Internal(w, r, "setup failed")
+			}
+			return
+		}
+
+		log.InfoContext(r.Context(), "administrator created with setup token", slog.Int64("user_id", userID))
+
+		render.JSON(w, r, SetupResponse{
+			Status: resp.OK().Status,
+			UserID: userID,
+		})
+	}
+}
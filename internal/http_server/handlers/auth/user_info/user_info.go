@@ -1,12 +1,8 @@
 package user_info
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
-	"strings"
-	"telephone-book/internal/clients/sso/grpc"
-	"telephone-book/internal/lib/logger/sl"
 
 	middleware "telephone-book/internal/http_server/middleware"
 	resp "telephone-book/internal/lib/response"
@@ -22,36 +18,20 @@ type UserInfoResponse struct {
 	Error  string `json:"error,omitempty"`
 }
 
-func UserInfo(ctx context.Context, log *slog.Logger) http.HandlerFunc {
+func UserInfo(log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.auth.user_info.UserInfo"
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			log.Warn("no authorization header")
-			render.JSON(w, r, resp.Error("unauthorized"))
-			return
-		}
-
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			log.Warn("invalid authorization header format")
-			render.JSON(w, r, resp.Error("unauthorized"))
-			return
-		}
-
-		token := parts[1]
-
-		// Извлекаем email из токена (используем тот же секрет что и в middleware)
-		email, err := grpc.ParseEmailFromToken(token, "test-secret")
-		if err != nil {
-			log.Error("failed to parse email from token", sl.Err(err))
-			render.JSON(w, r, resp.Error("invalid token"))
+		// Токен уже проверен в AuthMiddleware
+		email, ok := middleware.GetEmail(r.Context())
+		if !ok {
+			log.WarnContext(r.Context(), "no valid token in request")
+			resp.Unauthorized(w, r, "unauthorized")
 			return
 		}
 
@@ -69,7 +49,7 @@ func UserInfo(ctx context.Context, log *slog.Logger) http.HandlerFunc {
 			roleString = "guest"
 		}
 
-		log.Info("user info retrieved",
+		log.InfoContext(r.Context(), "user info retrieved",
 			slog.String("email", email),
 			slog.String("role", roleString))
 
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 
 	middleware "telephone-book/internal/http_server/middleware"
 	resp "telephone-book/internal/lib/response"
@@ -44,31 +45,27 @@ type DepatmentCreater interface {
 // @Produce json
 // @Param department body CreateRequest true "Данные отдела"
 // @Success 200 {object} CreateResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
 // @Router /departments [post]
-func Create(ctx context.Context, log *slog.Logger, departmentCreater DepatmentCreater) http.HandlerFunc {
+func Create(log *slog.Logger, departmentCreater DepatmentCreater, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.departments.create.Create"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			render.JSON(w, r, resp.Error("unauthorized: only admins can create departments"))
-			return
-		}
-
 		var req CreateRequest
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
 			msg := "failed to decode request body"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
@@ -77,17 +74,21 @@ func Create(ctx context.Context, log *slog.Logger, departmentCreater DepatmentCr
 			slog.String("department", req.Name),
 		)
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(ctx, "request body decoded", slog.Any("request", req))
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionDepartmentManage, policy.Scope{Institute: req.Institute}) {
+			return
+		}
 
 		departmentID, err := departmentCreater.CreateDepartment(ctx, req.Institute, req.Name, req.Sections)
 		if err != nil {
-			log.Error("failed to create department", sl.Err(err))
-			render.JSON(w, r, resp.Error(err.Error()))
+			msg := "failed to create department"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
-
 		}
 
-		log.Info("department successfully saved")
+		log.InfoContext(ctx, "department successfully saved")
 
 		createResponseOk(w, r, departmentID)
 	}
@@ -7,7 +7,9 @@ import (
 	"telephone-book/internal/http_server/middleware"
 	"telephone-book/internal/lib/logger/sl"
 	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/policy"
 
+	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
@@ -32,51 +34,51 @@ type DepartmentDeleter interface {
 // @Tags departments
 // @Produce json
 // @Param institute query string true "Институт"
-// @Param department query string true "Название отдела"
+// @Param department path string true "Название отдела"
 // @Success 200 {object} DeleteResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Router /departments [delete]
-func Delete(ctx context.Context, log *slog.Logger, departmentDeleter DepartmentDeleter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /departments/{department} [delete]
+func Delete(log *slog.Logger, departmentDeleter DepartmentDeleter, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.deparments.delete.Delete"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		department := r.URL.Query().Get("department")
+		department := chi.URLParam(r, "department")
 		if department == "" {
 			msg := "department not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			render.JSON(w, r, resp.Error("unauthorized: only admin can delete departments"))
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionDepartmentManage, policy.Scope{Institute: institute}) {
 			return
 		}
 
 		err := departmentDeleter.DeleteDepartment(ctx, institute, department)
 		if err != nil {
 			msg := "failed to delete user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully deleted",
+		log.InfoContext(ctx, "user successfully deleted",
 			slog.String("department", department),
 			slog.String("institute", institute))
 
@@ -43,13 +43,14 @@ type DepartmentsGetter interface {
 // @Produce json
 // @Param institute query string true "Институт"
 // @Success 200 {object} DepartmentsResponse
-// @Failure 400 {object} response.Response
+// @Failure 400 {object} response.Problem
 // @Router /departments [get]
-func GetAll(ctx context.Context, log *slog.Logger, departmnetsGetter DepartmentsGetter) http.HandlerFunc {
+func GetAll(log *slog.Logger, departmnetsGetter DepartmentsGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.depaerments.read.GetAll"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -57,8 +58,8 @@ func GetAll(ctx context.Context, log *slog.Logger, departmnetsGetter Departments
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -69,12 +70,12 @@ func GetAll(ctx context.Context, log *slog.Logger, departmnetsGetter Departments
 		departments, err := departmnetsGetter.GetAllDepartments(ctx, institute)
 		if err != nil {
 			msg := "failed to get departments"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("departments retrieved successfully", slog.Int("count", len(departments)))
+		log.InfoContext(ctx, "departments retrieved successfully", slog.Int("count", len(departments)))
 
 		DepartmentsResponseOk(w, r, departments)
 	}
@@ -87,13 +88,14 @@ func GetAll(ctx context.Context, log *slog.Logger, departmnetsGetter Departments
 // @Param institute query string true "Институт"
 // @Param department path string true "Название отдела"
 // @Success 200 {object} SectionsResponse
-// @Failure 400 {object} response.Response
-// @Router /departments/{department} [get]
-func GetSections(ctx context.Context, log *slog.Logger, departmnetsGetter DepartmentsGetter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Router /departments/{department}/sections [get]
+func GetSections(log *slog.Logger, departmnetsGetter DepartmentsGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.departments.read.GetSections"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -101,16 +103,16 @@ func GetSections(ctx context.Context, log *slog.Logger, departmnetsGetter Depart
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		department := chi.URLParam(r, "department")
 		if department == "" {
 			msg := "department not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -122,12 +124,12 @@ func GetSections(ctx context.Context, log *slog.Logger, departmnetsGetter Depart
 		sections, err := departmnetsGetter.GetSections(ctx, institute, department)
 		if err != nil {
 			msg := "failed to get sections"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("sections retrieved successfully", slog.Int("count", len(sections)))
+		log.InfoContext(ctx, "sections retrieved successfully", slog.Int("count", len(sections)))
 
 		SectionsResponseOk(w, r, sections)
 	}
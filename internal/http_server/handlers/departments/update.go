@@ -5,10 +5,12 @@ import (
 	"log/slog"
 	"net/http"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 
 	middleware "telephone-book/internal/http_server/middleware"
 	resp "telephone-book/internal/lib/response"
 
+	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
@@ -41,38 +43,39 @@ type DepartmentUpdater interface {
 // @Accept json
 // @Produce json
 // @Param institute query string true "Институт"
-// @Param department query string true "Старое название отдела"
+// @Param department path string true "Старое название отдела"
 // @Param department body UpdateRequest true "Новые данные отдела"
 // @Success 200 {object} UpdateResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Router /departments [put]
-func Update(ctx context.Context, log *slog.Logger, departmentUpdater DepartmentUpdater) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /departments/{department} [put]
+func Update(log *slog.Logger, departmentUpdater DepartmentUpdater, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.departments.update.Update"
-		log = log.With(
+		ctx := r.Context()
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			render.JSON(w, r, resp.Error("unauthorized: only authenticated admins can update workers"))
-			return
-		}
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 
 			return
 		}
-		oldName := r.URL.Query().Get("department")
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionDepartmentManage, policy.Scope{Institute: institute}) {
+			return
+		}
+
+		oldName := chi.URLParam(r, "department")
 		if oldName == "" {
 			msg := "department name is not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 
 			return
 		}
@@ -82,12 +85,12 @@ func Update(ctx context.Context, log *slog.Logger, departmentUpdater DepartmentU
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
 			msg := "failed to decode request body"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(ctx, "request body decoded", slog.Any("request", req))
 
 		err = departmentUpdater.UpdateDepartment(
 			ctx,
@@ -99,12 +102,12 @@ func Update(ctx context.Context, log *slog.Logger, departmentUpdater DepartmentU
 
 		if err != nil {
 			msg := "failed to update department"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully updated",
+		log.InfoContext(ctx, "user successfully updated",
 			slog.String("old_name", oldName),
 			slog.String("new_name", req.Name))
 
@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/lib/logger/sl"
+	"time"
+
+	resp "telephone-book/internal/lib/response"
+
+	"github.com/go-chi/render"
+)
+
+// Check проверка зависимости; nil — зависимость доступна
+type Check func(ctx context.Context) error
+
+const statusUnavailable = "Unavailable"
+
+type ReadyResponse struct {
+	// Пример: "Ok"
+	Status string `json:"status"`
+	// Результат по каждой зависимости: "ok" или "unavailable"; причина — в логе
+	Checks map[string]string `json:"checks"`
+}
+
+// Live отвечает, пока процесс обслуживает запросы. Зависимости не проверяет:
+// сбой БД или SSO не повод перезапускать сервис
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// Ready проверяет зависимости за timeout; если хоть одна недоступна — 503,
+// и балансировщик перестаёт направлять сюда запросы
+func Ready(log *slog.Logger, timeout time.Duration, checks map[string]Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.Ready"
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		response := ReadyResponse{
+			Status: resp.OK().Status,
+			Checks: make(map[string]string, len(checks)),
+		}
+
+		for name, check := range checks {
+			if err := check(ctx); err != nil {
+				log.WarnContext(ctx, "readiness check failed",
+					slog.String("operation", op),
+					slog.String("check", name),
+					sl.Err(err),
+				)
+				response.Status = statusUnavailable
+				response.Checks[name] = "unavailable"
+				continue
+			}
+			response.Checks[name] = "ok"
+		}
+
+		if response.Status != resp.OK().Status {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, response)
+	}
+}
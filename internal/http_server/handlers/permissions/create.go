@@ -0,0 +1,138 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type CreateRequest struct {
+	// id пользователя в SSO
+	UserID int64 `json:"user_id" validate:"required,min=1"`
+	// Роль: admin или editor
+	Role      string `json:"role" validate:"required"`
+	Institute string `json:"institute" validate:"required"`
+	// Отдел; пустой — право на весь институт. Роль admin выдаётся только на институт
+	Department string `json:"department,omitempty"`
+}
+
+type CreateResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Выданное право
+	Grant models.Grant `json:"grant"`
+}
+
+type GrantCreator interface {
+	CreateGrant(ctx context.Context, grant models.Grant) (models.Grant, error)
+}
+
+// Create выдаёт пользователю право на институт или отдел
+// @Summary Выдать право
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Param grant body CreateRequest true "Право"
+// @Success 200 {object} CreateResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /permissions [post]
+func Create(log *slog.Logger, grantCreator GrantCreator, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.permissions.create.Create"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		var req CreateRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			msg := "failed to decode request body"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(ctx, "invalid request", sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		if !policy.ValidRole(req.Role) {
+			msg := "invalid role: expected admin or editor"
+			log.ErrorContext(ctx, msg, slog.String("role", req.Role))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+		if req.Role == policy.RoleAdmin && req.Department != "" {
+			msg := "admin role can only be granted for the whole institute"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPermissionManage, policy.Scope{Institute: req.Institute}) {
+			return
+		}
+
+		createdBy, _ := middleware.GetUserID(r.Context())
+		grant, err := grantCreator.CreateGrant(ctx, models.Grant{
+			UserID:     req.UserID,
+			Role:       req.Role,
+			Institute:  req.Institute,
+			Department: req.Department,
+			CreatedBy:  createdBy,
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrGrantAlreadyExists) {
+				msg := "permission already granted"
+				log.InfoContext(ctx, msg)
+				resp.Conflict(w, r, msg)
+				return
+			}
+			if errors.Is(err, storage.ErrSchemaNotExist) {
+				msg := "institute not found"
+				log.ErrorContext(ctx, msg, slog.String("institute", req.Institute))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to grant permission"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "permission granted",
+			slog.Int64("grant_id", grant.ID),
+			slog.Int64("user_id", grant.UserID),
+			slog.String("role", grant.Role),
+			slog.String("institute", grant.Institute),
+			slog.String("department", grant.Department),
+			slog.Int64("created_by", createdBy),
+		)
+
+		render.JSON(w, r, CreateResponse{
+			Status: resp.OK().Status,
+			Grant:  grant,
+		})
+	}
+}
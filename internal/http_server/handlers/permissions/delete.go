@@ -0,0 +1,97 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type GrantDeleter interface {
+	GetGrant(ctx context.Context, id int64) (models.Grant, error)
+	DeleteGrant(ctx context.Context, id int64) error
+}
+
+// Delete отзывает право
+// @Summary Отозвать право
+// @Tags permissions
+// @Produce json
+// @Param id path int true "ID права"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /permissions/{id} [delete]
+func Delete(log *slog.Logger, grantDeleter GrantDeleter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.permissions.delete.Delete"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			msg := "invalid permission id"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		grant, err := grantDeleter.GetGrant(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrGrantNotFound) {
+				msg := "permission not found"
+				log.InfoContext(ctx, msg, slog.Int64("grant_id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to get permission"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPermissionManage, policy.Scope{Institute: grant.Institute}) {
+			return
+		}
+
+		err = grantDeleter.DeleteGrant(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrGrantNotFound) {
+				msg := "permission not found"
+				log.InfoContext(ctx, msg, slog.Int64("grant_id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to revoke permission"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		revokedBy, _ := middleware.GetUserID(r.Context())
+		log.InfoContext(ctx, "permission revoked",
+			slog.Int64("grant_id", id),
+			slog.Int64("user_id", grant.UserID),
+			slog.Int64("revoked_by", revokedBy),
+		)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
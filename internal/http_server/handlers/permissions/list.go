@@ -0,0 +1,76 @@
+package permissions
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type GrantsGetter interface {
+	GetInstituteGrants(ctx context.Context, institute string) ([]models.Grant, error)
+}
+
+type ListResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Права, выданные в институте
+	Grants []models.Grant `json:"grants"`
+}
+
+// List возвращает права, выданные в институте
+// @Summary Права института
+// @Tags permissions
+// @Produce json
+// @Param institute query string true "Институт"
+// @Success 200 {object} ListResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /permissions [get]
+func List(log *slog.Logger, grantsGetter GrantsGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.permissions.list.List"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPermissionManage, policy.Scope{Institute: institute}) {
+			return
+		}
+
+		grants, err := grantsGetter.GetInstituteGrants(ctx, institute)
+		if err != nil {
+			msg := "failed to get permissions"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		render.JSON(w, r, ListResponse{
+			Status: resp.OK().Status,
+			Grants: grants,
+		})
+	}
+}
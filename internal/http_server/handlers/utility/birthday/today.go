@@ -23,13 +23,14 @@ type BirthdayGetter interface {
 // @Produce json
 // @Param institute query string true "Институт"
 // @Success 200 {array} models.User
-// @Failure 400 {object} response.Response
-// @Router /birthday/today [get]
-func Today(ctx context.Context, log *slog.Logger, birthdayGetter BirthdayGetter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Router /birthdays/today [get]
+func Today(log *slog.Logger, birthdayGetter BirthdayGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.utility.birthday.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", r.Header.Get("X-Request-ID")),
 		)
@@ -37,20 +38,20 @@ func Today(ctx context.Context, log *slog.Logger, birthdayGetter BirthdayGetter)
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute parameter is required"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		birthdays, err := birthdayGetter.GetTodaysBirthdays(ctx, institute)
 		if err != nil {
 			msg := "failed to get birthdays"
-			log.Error(msg, slog.String("institute", institute), sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, slog.String("institute", institute), sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("birthdays retrieved successfully", slog.Int("count", len(birthdays)))
+		log.InfoContext(ctx, "birthdays retrieved successfully", slog.Int("count", len(birthdays)))
 
 		render.JSON(w, r, birthdays)
 
@@ -1,7 +1,6 @@
 package birthday
 
 import (
-	"context"
 	"log/slog"
 	"net/http"
 	"telephone-book/internal/lib/logger/sl"
@@ -17,13 +16,14 @@ import (
 // @Produce json
 // @Param institute query string true "Институт"
 // @Success 200 {array} models.User
-// @Failure 400 {object} response.Response
-// @Router /birthday/tomorrow [get]
-func Tomorrow(ctx context.Context, log *slog.Logger, birthdayGetter BirthdayGetter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Router /birthdays/tomorrow [get]
+func Tomorrow(log *slog.Logger, birthdayGetter BirthdayGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.utility.birthday.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", r.Header.Get("X-Request-ID")),
 		)
@@ -31,20 +31,20 @@ func Tomorrow(ctx context.Context, log *slog.Logger, birthdayGetter BirthdayGett
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute parameter is required"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		birthdays, err := birthdayGetter.GetTomorrowsBirthdays(ctx, institute)
 		if err != nil {
 			msg := "failed to get birthdays"
-			log.Error(msg, slog.String("institute", institute), sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, slog.String("institute", institute), sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("birthdays retrieved successfully", slog.Int("count", len(birthdays)))
+		log.InfoContext(ctx, "birthdays retrieved successfully", slog.Int("count", len(birthdays)))
 
 		render.JSON(w, r, birthdays)
 
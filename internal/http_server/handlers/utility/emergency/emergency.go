@@ -31,25 +31,26 @@ type EmergencyResponse struct {
 // @Tags emergency
 // @Produce json
 // @Success 200 {object} EmergencyResponse
-// @Failure 400 {object} response.Response
-// @Router /emergency [get]
-func New(ctx context.Context, log *slog.Logger, emergencyProvider EmergencyProvider) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Router /emergency-services [get]
+func New(log *slog.Logger, emergencyProvider EmergencyProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.utility.emergency.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
 		services, err := emergencyProvider.Emergency(ctx)
 		if err != nil {
-			log.Error("failed to get emergency services", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to retrieve emergency services"))
+			log.ErrorContext(ctx, "failed to get emergency services", sl.Err(err))
+			resp.FromError(w, r, err, "failed to retrieve emergency services")
 			return
 		}
 
-		log.Info("emergency services retrieved successfully", slog.Int("count", len(services)))
+		log.InfoContext(ctx, "emergency services retrieved successfully", slog.Int("count", len(services)))
 
 		responseOk(w, r, services)
 	}
@@ -2,96 +2,147 @@ package imports
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"telephone-book/internal/domain/models"
 	middleware "telephone-book/internal/http_server/middleware"
+	"telephone-book/internal/importer"
 	"telephone-book/internal/lib/logger/sl"
-	"telephone-book/internal/lib/parser"
 	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/policy"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
 
-type UserImporter interface {
-	ImportUsers(ctx context.Context, isntitue string, users []models.User) error
+type ImportSubmitter interface {
+	Submit(ctx context.Context, institute string, format string, missingOrgUnits string, data []byte, createdBy int64) (models.ImportJob, error)
 }
 
-// New импортирует пользователей из файла
+type SubmitResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Задача импорта, статус которой можно запрашивать по /imports/{id}
+	Job models.ImportJob `json:"job"`
+}
+
+// New ставит в очередь импорт пользователей из Excel файла
 // @Summary Импорт пользователей
 // @Tags import
 // @Accept multipart/form-data
 // @Produce json
 // @Param institute query string true "Институт"
+// @Param missing_org_units query string false "Несуществующие отделы и секции: keep, create (только для администраторов), reject" default(keep)
 // @Param file formData file true "Файл с пользователями"
-// @Success 200 {object} response.Response
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Router /workers/import [post]
-
-func New(ctx context.Context, log *slog.Logger, userCreater UserImporter) http.HandlerFunc {
+// @Success 200 {object} SubmitResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /imports [post]
+func New(log *slog.Logger, importSubmitter ImportSubmitter, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.utility.imports.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		role := middleware.GetRole(r.Context(), log)
-		if role == middleware.RoleGuest {
-			render.JSON(w, r, resp.Error("unauthorized: only authenticated users can import data"))
-			return
-		}
+		submit(w, r, log, importSubmitter, authorizer, importer.FormatExcel)
+	}
+}
 
-		institute := r.URL.Query().Get("institute")
-		if institute == "" {
-			msg := "institute parameter is required"
-			log.Error(msg)
-			http.Error(w, msg, http.StatusBadRequest)
-			return
-		}
+// submit читает файл из формы и создаёт задачу импорта
+func submit(w http.ResponseWriter, r *http.Request, log *slog.Logger, importSubmitter ImportSubmitter, authorizer middleware.Authorizer, format string) {
+	institute := r.URL.Query().Get("institute")
+	if institute == "" {
+		msg := "institute parameter is required"
+		log.ErrorContext(r.Context(), msg)
+		resp.BadRequest(w, r, msg)
+		return
+	}
 
-		err := r.ParseMultipartForm(100 << 20) // 100 MB limit
-		if err != nil {
-			log.Error("failed to parse multipart form", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to parse form data"))
+	if !middleware.Authorize(w, r, log, authorizer, policy.ActionImport, policy.Scope{Institute: institute}) {
+		return
+	}
 
-			return
-		}
+	missingOrgUnits := r.URL.Query().Get("missing_org_units")
+	if missingOrgUnits == "" {
+		missingOrgUnits = importer.OrgUnitsKeep
+	}
+	if !importer.ValidOrgUnitsMode(missingOrgUnits) {
+		msg := "invalid missing_org_units parameter: expected keep, create or reject"
+		log.ErrorContext(r.Context(), msg, slog.String("missing_org_units", missingOrgUnits))
+		resp.BadRequest(w, r, msg)
+		return
+	}
 
-		file, _, err := r.FormFile("file")
-		if err != nil {
-			log.Error("failed to get file from form", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to get file from form"))
+	// Справочник отделов меняет только тот, кому разрешено управлять отделами института
+	if missingOrgUnits == importer.OrgUnitsCreate &&
+		!middleware.Authorize(w, r, log, authorizer, policy.ActionDepartmentManage, policy.Scope{Institute: institute}) {
+		return
+	}
 
-			return
-		}
-		defer func(file multipart.File) {
-			err := file.Close()
-			if err != nil {
-				log.Warn("failed to close file", sl.Err(err))
-			}
-		}(file)
-
-		users, err := parser.Excel(file)
-		if err != nil {
-			log.Error("failed to parse excel file", sl.Err(err))
-			render.JSON(w, r, resp.Error("failed to parse excel file"))
+	err := r.ParseMultipartForm(100 << 20) // 100 MB limit
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to parse multipart form", sl.Err(err))
+		resp.BodyError(w, r, err, "failed to parse form data")
 
-			return
-		}
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get file from form", sl.Err(err))
+		resp.BadRequest(w, r, "failed to get file from form")
 
-		err = userCreater.ImportUsers(ctx, institute, users)
+		return
+	}
+	defer func(file multipart.File) {
+		err := file.Close()
 		if err != nil {
-			msg := "failed to import users"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
-			return
+			log.WarnContext(r.Context(), "failed to close file", sl.Err(err))
 		}
+	}(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read file", sl.Err(err))
+		resp.BodyError(w, r, err, "failed to read file")
+		return
+	}
 
-		render.JSON(w, r, resp.OK())
+	userID, _ := middleware.GetUserID(r.Context())
+
+	job, err := importSubmitter.Submit(r.Context(), institute, format, missingOrgUnits, data, userID)
+	if err != nil {
+		if errors.Is(err, importer.ErrQueueFull) {
+			msg := "too many imports in progress, try again later"
+			log.WarnContext(r.Context(), msg)
+			resp.Unavailable(w, r, msg)
+			return
+		}
+		msg := "failed to submit import job"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.FromError(w, r, err, msg)
+		return
 	}
+
+	log.InfoContext(r.Context(), "import job submitted",
+		slog.Int64("job_id", job.ID),
+		slog.String("institute", institute),
+		slog.String("format", format),
+		slog.String("missing_org_units", missingOrgUnits),
+		slog.Int("size", len(data)),
+	)
+
+	render.JSON(w, r, SubmitResponse{
+		Status: resp.OK().Status,
+		Job:    job,
+	})
 }
@@ -0,0 +1,198 @@
+package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	middleware "telephone-book/internal/http_server/middleware"
+	"telephone-book/internal/importer"
+	"telephone-book/internal/lib/logger/sl"
+	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type JobGetter interface {
+	GetImportJob(ctx context.Context, id int64) (models.ImportJob, error)
+}
+
+type JobCanceller interface {
+	Cancel(ctx context.Context, id int64) error
+}
+
+type JobResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Задача импорта
+	Job models.ImportJob `json:"job"`
+}
+
+// Status возвращает состояние и прогресс задачи импорта
+// @Summary Статус задачи импорта
+// @Tags import
+// @Produce json
+// @Param id path int true "ID задачи"
+// @Success 200 {object} JobResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /imports/{id} [get]
+func Status(log *slog.Logger, jobGetter JobGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.imports.Status"
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		job, ok := getJob(w, r, log, jobGetter, authorizer)
+		if !ok {
+			return
+		}
+
+		render.JSON(w, r, JobResponse{
+			Status: resp.OK().Status,
+			Job:    job,
+		})
+	}
+}
+
+// Cancel отменяет задачу импорта; уже импортированные строки остаются в базе
+// @Summary Отменить задачу импорта
+// @Tags import
+// @Produce json
+// @Param id path int true "ID задачи"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /imports/{id} [delete]
+func Cancel(log *slog.Logger, jobGetter JobGetter, jobCanceller JobCanceller, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.imports.Cancel"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		job, ok := getJob(w, r, log, jobGetter, authorizer)
+		if !ok {
+			return
+		}
+		id := job.ID
+
+		err := jobCanceller.Cancel(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrImportJobNotFound) {
+				msg := "import job not found"
+				log.InfoContext(ctx, msg, slog.Int64("job_id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			if errors.Is(err, importer.ErrJobFinished) {
+				msg := "import job already finished"
+				log.InfoContext(ctx, msg, slog.Int64("job_id", id))
+				resp.Conflict(w, r, msg)
+				return
+			}
+			msg := "failed to cancel import job"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "import job cancelled", slog.Int64("job_id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// Report отдаёт отчёт об ошибках импорта в CSV
+// @Summary Отчёт об ошибках импорта
+// @Tags import
+// @Produce text/csv
+// @Param id path int true "ID задачи"
+// @Success 200 {file} binary "CSV: строка, email, ошибка"
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /imports/{id}/report [get]
+func Report(log *slog.Logger, jobGetter JobGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.imports.Report"
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		job, ok := getJob(w, r, log, jobGetter, authorizer)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, job.ID))
+		w.WriteHeader(http.StatusOK)
+
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"row", "email", "error"})
+		for _, rowErr := range job.Errors {
+			_ = cw.Write([]string{strconv.Itoa(rowErr.Row), rowErr.Email, rowErr.Error})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			log.ErrorContext(r.Context(), "failed to write report", sl.Err(err))
+			return
+		}
+
+		log.InfoContext(r.Context(), "import report served", slog.Int64("job_id", job.ID), slog.Int("errors", len(job.Errors)))
+	}
+}
+
+func getJob(w http.ResponseWriter, r *http.Request, log *slog.Logger, jobGetter JobGetter, authorizer middleware.Authorizer) (models.ImportJob, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		msg := "invalid job id"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.BadRequest(w, r, msg)
+		return models.ImportJob{}, false
+	}
+
+	job, err := jobGetter.GetImportJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrImportJobNotFound) {
+			msg := "import job not found"
+			log.InfoContext(r.Context(), msg, slog.Int64("job_id", id))
+			resp.NotFound(w, r, msg)
+			return models.ImportJob{}, false
+		}
+		msg := "failed to get import job"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.FromError(w, r, err, msg)
+		return models.ImportJob{}, false
+	}
+
+	if !middleware.Authorize(w, r, log, authorizer, policy.ActionImport, policy.Scope{Institute: job.Institute}) {
+		return models.ImportJob{}, false
+	}
+
+	return job, true
+}
@@ -0,0 +1,36 @@
+package imports
+
+import (
+	"log/slog"
+	"net/http"
+	middleware "telephone-book/internal/http_server/middleware"
+	"telephone-book/internal/importer"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// VCard ставит в очередь импорт пользователей из vcf файла
+// @Summary Импорт пользователей из vCard
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param institute query string true "Институт"
+// @Param missing_org_units query string false "Несуществующие отделы и секции: keep, create (только для администраторов), reject" default(keep)
+// @Param file formData file true "vcf файл с одной или несколькими карточками"
+// @Success 200 {object} SubmitResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /imports/vcard [post]
+func VCard(log *slog.Logger, importSubmitter ImportSubmitter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.imports.VCard"
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		submit(w, r, log, importSubmitter, authorizer, importer.FormatVCard)
+	}
+}
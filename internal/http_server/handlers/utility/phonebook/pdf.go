@@ -0,0 +1,150 @@
+package phonebook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/lib/pdf"
+
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	layoutCompact  = "compact"
+	layoutDetailed = "detailed"
+)
+
+type PhonebookProvider interface {
+	Emergency(ctx context.Context) ([]models.Service, error)
+	GetAllUsers(ctx context.Context, institute string, department string, section string) ([]models.User, error)
+	GetUserPhotos(ctx context.Context, institute string, emails []string) (map[string][]byte, error)
+}
+
+// PDF формирует печатный справочник института; generator nil, если шрифты не загрузились
+// @Summary Печатный справочник в PDF
+// @Tags export
+// @Produce application/pdf
+// @Param institute query string true "Институт"
+// @Param layout query string false "Вёрстка: compact или detailed (по умолчанию)"
+// @Param photos query bool false "Добавлять фотографии (по умолчанию false)"
+// @Success 200 {file} binary "PDF"
+// @Failure 400 {object} response.Problem
+// @Failure 503 {object} response.Problem
+// @Router /exports/pdf [get]
+func PDF(log *slog.Logger, provider PhonebookProvider, generator *pdf.Generator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.phonebook.PDF"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		if generator == nil {
+			msg := "pdf export is unavailable"
+			log.ErrorContext(ctx, msg)
+			resp.Unavailable(w, r, msg)
+			return
+		}
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		opts := pdf.Options{Title: institute}
+
+		switch layout := r.URL.Query().Get("layout"); layout {
+		case "", layoutDetailed:
+		case layoutCompact:
+			opts.Compact = true
+		default:
+			msg := "invalid layout (allowed: compact, detailed)"
+			log.ErrorContext(ctx, msg, slog.String("layout", layout))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		if photos := r.URL.Query().Get("photos"); photos != "" {
+			var err error
+			opts.Photos, err = strconv.ParseBool(photos)
+			if err != nil {
+				msg := "invalid photos parameter"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.BadRequest(w, r, msg)
+				return
+			}
+		}
+
+		log = log.With(
+			slog.String("institute", institute),
+			slog.Bool("compact", opts.Compact),
+			slog.Bool("photos", opts.Photos),
+		)
+
+		services, err := provider.Emergency(ctx)
+		if err != nil {
+			msg := "failed to get emergency services"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		users, err := provider.GetAllUsers(ctx, institute, "", "")
+		if err != nil {
+			msg := "failed to get users"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		if opts.Photos {
+			emails := make([]string, len(users))
+			for i, user := range users {
+				emails[i] = user.Email
+			}
+			photos, err := provider.GetUserPhotos(ctx, institute, emails)
+			if err != nil {
+				msg := "failed to get user photos"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+			for i := range users {
+				users[i].Photo = photos[users[i].Email]
+			}
+		}
+
+		// Рендерим в буфер, чтобы при ошибке вернуть JSON, а не обрезанный файл
+		var buf bytes.Buffer
+		if err := generator.Render(&buf, services, users, opts); err != nil {
+			msg := "failed to render pdf"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape("phonebook-"+institute+".pdf")))
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusOK)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.ErrorContext(ctx, "failed to write pdf", sl.Err(err))
+			return
+		}
+
+		log.InfoContext(ctx, "phonebook rendered successfully", slog.Int("workers", len(users)))
+	}
+}
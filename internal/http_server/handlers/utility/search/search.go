@@ -8,6 +8,7 @@ import (
 
 	"telephone-book/internal/lib/logger/sl"
 	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/metrics"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -35,13 +36,14 @@ type AllUsersResponse struct {
 // @Param section query string false "Секция"
 // @Param query query string true "Строка поиска"
 // @Success 200 {object} AllUsersResponse
-// @Failure 400 {object} response.Response
+// @Failure 400 {object} response.Problem
 // @Router /search [get]
-func New(ctx context.Context, log *slog.Logger, usersSearcher UsersSearcher) http.HandlerFunc {
+func New(log *slog.Logger, usersSearcher UsersSearcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.search.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
@@ -49,8 +51,8 @@ func New(ctx context.Context, log *slog.Logger, usersSearcher UsersSearcher) htt
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -60,8 +62,8 @@ func New(ctx context.Context, log *slog.Logger, usersSearcher UsersSearcher) htt
 		query := r.URL.Query().Get("query")
 		if query == "" {
 			msg := "query not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -74,12 +76,13 @@ func New(ctx context.Context, log *slog.Logger, usersSearcher UsersSearcher) htt
 		users, err := usersSearcher.Search(ctx, institute, department, section, query)
 		if err != nil {
 			msg := "failed to search users"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("users found", slog.Int("count", len(users)))
+		log.InfoContext(ctx, "users found", slog.Int("count", len(users)))
+		metrics.ObserveSearch(len(users))
 
 		responseOk(w, r, users)
 	}
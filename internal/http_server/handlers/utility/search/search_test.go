@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/http_server/middleware"
+	"testing"
+	"time"
+)
+
+// blockingSearcher ждёт отмены контекста, как запрос к БД, прерванный драйвером
+type blockingSearcher struct {
+	ctxErr chan error
+}
+
+func (s blockingSearcher) Search(ctx context.Context, _, _, _, _ string) ([]models.User, error) {
+	<-ctx.Done()
+	s.ctxErr <- ctx.Err()
+	return nil, errors.New("pq: canceling statement due to user request")
+}
+
+func serve(ctx context.Context, deadline time.Duration) (*httptest.ResponseRecorder, error) {
+	searcher := blockingSearcher{ctxErr: make(chan error, 1)}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := middleware.Deadline(deadline)(New(log, searcher))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/search?institute=grafit&query=Иванов", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w, <-searcher.ctxErr
+}
+
+func TestSearchDeadline(t *testing.T) {
+	w, ctxErr := serve(context.Background(), 20*time.Millisecond)
+
+	if !errors.Is(ctxErr, context.DeadlineExceeded) {
+		t.Fatalf("storage saw %v, want context.DeadlineExceeded", ctxErr)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+}
+
+func TestSearchClientCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w, ctxErr := serve(ctx, time.Minute)
+
+	if !errors.Is(ctxErr, context.Canceled) {
+		t.Fatalf("storage saw %v, want context.Canceled", ctxErr)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("response written for a cancelled request: %d %s", w.Code, w.Body)
+	}
+}
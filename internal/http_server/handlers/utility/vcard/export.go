@@ -0,0 +1,105 @@
+package vcard
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+type UsersExporter interface {
+	GetAllUsers(ctx context.Context, institute string, department string, section string) ([]models.User, error)
+	Search(ctx context.Context, institute string, department string, section string, query string) ([]models.User, error)
+	GetUserPhotos(ctx context.Context, institute string, emails []string) (map[string][]byte, error)
+}
+
+// Export отдаёт работников отдела или результаты поиска одним vcf файлом. Гостям недоступен:
+// маршрут закрыт middleware.RequireAuth
+// @Summary Экспорт отдела или результатов поиска в vCard
+// @Tags vcard
+// @Produce text/vcard
+// @Param institute query string true "Институт"
+// @Param department query string false "Отдел"
+// @Param section query string false "Секция"
+// @Param query query string false "Строка поиска (если указана, экспортируются результаты поиска)"
+// @Param version query string false "Версия vCard: 3.0 (по умолчанию) или 4.0"
+// @Success 200 {file} binary "vCard"
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Router /exports/vcard [get]
+func Export(log *slog.Logger, usersExporter UsersExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.vcard.Export"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		version, ok := versionParam(r)
+		if !ok {
+			msg := "unsupported vcard version (allowed: 3.0, 4.0)"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		department := r.URL.Query().Get("department")
+		section := r.URL.Query().Get("section")
+		query := r.URL.Query().Get("query")
+
+		log = log.With(
+			slog.String("institute", institute),
+			slog.String("department", department),
+			slog.String("section", section),
+			slog.String("query", query),
+		)
+
+		var users []models.User
+		var err error
+		if query != "" {
+			users, err = usersExporter.Search(ctx, institute, department, section, query)
+		} else {
+			users, err = usersExporter.GetAllUsers(ctx, institute, department, section)
+		}
+		if err != nil {
+			msg := "failed to get users"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		emails := make([]string, len(users))
+		for i, user := range users {
+			emails[i] = user.Email
+		}
+		photos, err := usersExporter.GetUserPhotos(ctx, institute, emails)
+		if err != nil {
+			msg := "failed to get user photos"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+		for i := range users {
+			users[i].Photo = photos[users[i].Email]
+		}
+
+		writeCards(w, r, log, users, version, "contacts.vcf")
+
+		log.InfoContext(ctx, "vcards exported successfully", slog.Int("count", len(users)))
+	}
+}
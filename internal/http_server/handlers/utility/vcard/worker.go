@@ -0,0 +1,127 @@
+package vcard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/lib/vcard"
+	"telephone-book/internal/storage"
+
+	resp "telephone-book/internal/lib/response"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+type WorkerGetter interface {
+	GetUserByID(ctx context.Context, institute string, id int) (models.User, error)
+	GetUserPhoto(ctx context.Context, institute string, email string) ([]byte, error)
+}
+
+// Worker отдаёт карточку работника в формате vCard
+// @Summary Экспорт работника в vCard
+// @Tags vcard
+// @Produce text/vcard
+// @Param id path int true "ID работника"
+// @Param institute query string true "Институт"
+// @Param version query string false "Версия vCard: 3.0 (по умолчанию) или 4.0"
+// @Success 200 {file} binary "vCard"
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /workers/{id}.vcf [get]
+func Worker(log *slog.Logger, workerGetter WorkerGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utility.vcard.Worker"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		// Расширение .vcf отрезает middleware.URLFormat
+		if format, _ := r.Context().Value(chimw.URLFormatCtxKey).(string); format != "vcf" {
+			msg := "unsupported format, use .vcf"
+			log.ErrorContext(ctx, msg, slog.String("format", format))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			msg := "invalid worker id"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		version, ok := versionParam(r)
+		if !ok {
+			msg := "unsupported vcard version (allowed: 3.0, 4.0)"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		user, err := workerGetter.GetUserByID(ctx, institute, id)
+		if err != nil {
+			if err == storage.ErrUserNotFound {
+				msg := "user not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+
+			msg := "failed to get user"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		user.Photo, err = workerGetter.GetUserPhoto(ctx, institute, user.Email)
+		if err != nil {
+			msg := "failed to get user photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		writeCards(w, r, log, []models.User{user}, version, fmt.Sprintf("worker-%d.vcf", id))
+
+		log.InfoContext(ctx, "vcard exported successfully", slog.Int("id", id))
+	}
+}
+
+// versionParam возвращает версию vCard из query параметра version
+func versionParam(r *http.Request) (string, bool) {
+	switch version := r.URL.Query().Get("version"); version {
+	case "", "3", vcard.Version3:
+		return vcard.Version3, true
+	case "4", vcard.Version4:
+		return vcard.Version4, true
+	default:
+		return "", false
+	}
+}
+
+func writeCards(w http.ResponseWriter, r *http.Request, log *slog.Logger, users []models.User, version string, filename string) {
+	w.Header().Set("Content-Type", vcard.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	if err := vcard.Write(w, users, version); err != nil {
+		log.ErrorContext(r.Context(), "failed to write vcard", sl.Err(err))
+	}
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/avatar"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+
+	resp "telephone-book/internal/lib/response"
+)
+
+const (
+	fallbackInitials = "initials"
+
+	avatarFormatSVG = "svg"
+	avatarFormatPNG = "png"
+
+	defaultAvatarSize = 256
+)
+
+// Аватар живёт меньше фото: после загрузки фотографии браузер должен быстро её увидеть
+const avatarCacheControl = "public, max-age=300"
+
+type avatarOptions struct {
+	enabled bool
+	format  string
+}
+
+// parseAvatarOptions читает fallback=initials и format=svg|png
+func parseAvatarOptions(r *http.Request) (avatarOptions, error) {
+	query := r.URL.Query()
+
+	var opts avatarOptions
+	switch fallback := query.Get("fallback"); fallback {
+	case "":
+		return opts, nil
+	case fallbackInitials:
+		opts.enabled = true
+	default:
+		return opts, errors.New("invalid fallback parameter: expected initials")
+	}
+
+	opts.format = query.Get("format")
+	switch opts.format {
+	case "":
+		opts.format = avatarFormatSVG
+	case avatarFormatSVG, avatarFormatPNG:
+	default:
+		return opts, errors.New("invalid format parameter: expected svg or png")
+	}
+
+	return opts, nil
+}
+
+// writeAvatar отдаёт аватар с инициалами работника вместо отсутствующей фотографии
+func writeAvatar(w http.ResponseWriter, r *http.Request, log *slog.Logger, user models.User, opts avatarOptions) {
+	initials := avatar.Initials(user.Surname, user.Name)
+	background := avatar.Color(user.Email)
+
+	if opts.format == avatarFormatSVG {
+		writeImage(w, r, log, avatar.SVG(initials, background), avatar.ContentTypeSVG, avatarCacheControl)
+		return
+	}
+
+	size := defaultAvatarSize
+	if value := r.URL.Query().Get("size"); value != "" {
+		if requested, err := strconv.Atoi(value); err == nil && requested > 0 {
+			size = imaging.ThumbnailSize(requested)
+		}
+	}
+
+	data, err := avatar.PNG(initials, background, size)
+	if err != nil {
+		msg := "failed to render avatar"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.FromError(w, r, err, msg)
+		return
+	}
+
+	writeImage(w, r, log, data, avatar.ContentTypePNG, avatarCacheControl)
+}
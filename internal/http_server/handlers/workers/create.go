@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 	"time"
 
@@ -17,21 +18,20 @@ import (
 	"github.com/go-playground/validator"
 )
 
-const maxPhotoSizeCreate = 5 * 1024 * 1024 // 5 MB
-
 type CreateRequest struct {
-	Institute   string    `json:"institute" validate:"required"`
-	Surname     string    `json:"surname" validate:"required"`
-	Name        string    `json:"name" validate:"required"`
-	MiddleName  string    `json:"middle_name,omitempty"`
-	Email       string    `json:"email" validate:"required,email"`
-	PhoneNumber string    `json:"phone_number" validate:"required"`
-	Cabinet     string    `json:"cabinet,omitempty"`
-	Position    string    `json:"position,omitempty"`
-	Department  string    `json:"department,omitempty"`
-	Section     string    `json:"section,omitempty"`
-	BirthDate   time.Time `json:"birth_date,omitempty"`
-	Description string    `json:"description,omitempty"`
+	Institute       string    `json:"institute" validate:"required"`
+	Surname         string    `json:"surname" validate:"required"`
+	Name            string    `json:"name" validate:"required"`
+	MiddleName      string    `json:"middle_name,omitempty"`
+	Email           string    `json:"email" validate:"required,email"`
+	PersonnelNumber string    `json:"personnel_number,omitempty"`
+	PhoneNumber     string    `json:"phone_number" validate:"required"`
+	Cabinet         string    `json:"cabinet,omitempty"`
+	Position        string    `json:"position,omitempty"`
+	Department      string    `json:"department,omitempty"`
+	Section         string    `json:"section,omitempty"`
+	BirthDate       time.Time `json:"birth_date,omitempty"`
+	Description     string    `json:"description,omitempty"`
 }
 
 type CreateResponse struct {
@@ -51,6 +51,7 @@ type UserCreater interface {
 		name string,
 		middlename string,
 		email string,
+		personnelNumber string,
 		phoneNumber string,
 		cabinet string,
 		position string,
@@ -69,41 +70,42 @@ type UserCreater interface {
 // @Produce json
 // @Param worker body CreateRequest true "Данные работника"
 // @Success 200 {object} CreateResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
 // @Router /workers [post]
-func Create(ctx context.Context, log *slog.Logger, userCreater UserCreater) http.HandlerFunc {
+func Create(log *slog.Logger, userCreater UserCreater, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.create.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		role := middleware.GetRole(r.Context(), log)
-		if role == middleware.RoleGuest {
-			render.JSON(w, r, resp.Error("unauthorized: only authenticated users can create workers"))
-			return
-		}
-
 		var req CreateRequest
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
 			msg := "failed to decode request body"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(ctx, "request body decoded", slog.String("institute", req.Institute), slog.String("email", req.Email))
 
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			msg := "invalid request"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		// Создавать работников могут редакторы института или отдела, в который он попадает
+		scope := policy.Scope{Institute: req.Institute, Department: req.Department}
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionWorkerCreate, scope) {
 			return
 		}
 
@@ -114,6 +116,7 @@ func Create(ctx context.Context, log *slog.Logger, userCreater UserCreater) http
 			req.Name,
 			req.MiddleName,
 			req.Email,
+			req.PersonnelNumber,
 			req.PhoneNumber,
 			req.Cabinet,
 			req.Position,
@@ -126,19 +129,19 @@ func Create(ctx context.Context, log *slog.Logger, userCreater UserCreater) http
 
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
 			msg := "user already exists"
-			log.Warn(msg, slog.String("email", req.Email))
-			render.JSON(w, r, resp.Error(msg))
+			log.WarnContext(ctx, msg, slog.String("email", req.Email))
+			resp.Conflict(w, r, msg)
 			return
 		}
 
 		if err != nil {
 			msg := "failed to save user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully saved", slog.String("email", req.Email))
+		log.InfoContext(ctx, "user successfully saved", slog.String("email", req.Email))
 
 		createResponseOk(w, r, userID)
 	}
@@ -3,13 +3,15 @@ package workers
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 	"time"
 
@@ -17,20 +19,16 @@ import (
 	resp "telephone-book/internal/lib/response"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
 )
 
-const maxPhotoSizeWithPhoto = 5 * 1024 * 1024 // 5 MB
-
-var allowedImageTypes = map[string]bool{
-	"image/jpeg": true,
-	"image/jpg":  true,
-	"image/png":  true,
-	"image/gif":  true,
-	"image/webp": true,
+type UserWithPhotoCreater interface {
+	UserCreater
+	PendingPhotoSubmitter
+	UpdateUserPhotoCrop(ctx context.Context, institute string, email string, crop *models.CropRect, thumbnails map[int][]byte) error
 }
 
-// CreateWithPhoto создает нового работника с фотографией
+// CreateWithPhoto создает нового работника с фотографией.
+// В институтах с модерацией фото от не-администраторов попадает в очередь на одобрение.
 // @Summary Создать работника с фотографией
 // @Tags workers
 // @Accept multipart/form-data
@@ -40,6 +38,7 @@ var allowedImageTypes = map[string]bool{
 // @Param name formData string true "Имя"
 // @Param middle_name formData string false "Отчество"
 // @Param email formData string true "Email"
+// @Param personnel_number formData string false "Табельный номер"
 // @Param phone_number formData string true "Рабочий телефон"
 // @Param cabinet formData string false "Кабинет"
 // @Param position formData string false "Должность"
@@ -48,32 +47,31 @@ var allowedImageTypes = map[string]bool{
 // @Param birth_date formData string false "Дата рождения (YYYY-MM-DD)"
 // @Param description formData string false "Описание"
 // @Param photo formData file false "Фотография (max 5MB)"
+// @Param crop_x formData int false "Левый край области кадрирования, px"
+// @Param crop_y formData int false "Верхний край области кадрирования, px"
+// @Param crop_width formData int false "Ширина области кадрирования, px"
+// @Param crop_height formData int false "Высота области кадрирования, px"
 // @Success 200 {object} CreateResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
 // @Router /workers/with-photo [post]
-func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCreater) http.HandlerFunc {
+func CreateWithPhoto(log *slog.Logger, userCreater UserWithPhotoCreater, authorizer middleware.Authorizer, moderation PhotoModeration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.create_with_photo.CreateWithPhoto"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		role := middleware.GetRole(r.Context(), log)
-		if role == middleware.RoleGuest {
-			render.JSON(w, r, resp.Error("unauthorized: only authenticated users can create workers"))
-			return
-		}
-
 		// Ограничиваем размер запроса
-		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSizeWithPhoto+1024*1024) // +1MB для остальных данных
+		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+1024*1024) // +1MB для остальных данных
 
-		if err := r.ParseMultipartForm(maxPhotoSizeWithPhoto); err != nil {
+		if err := r.ParseMultipartForm(maxPhotoSize); err != nil {
 			msg := "failed to parse multipart form"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
@@ -85,28 +83,29 @@ func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCrea
 		phoneNumber := strings.TrimSpace(r.FormValue("phone_number"))
 
 		if institute == "" {
-			render.JSON(w, r, resp.Error("institute is required"))
+			resp.BadRequest(w, r, "institute is required")
 			return
 		}
 		if surname == "" {
-			render.JSON(w, r, resp.Error("surname is required"))
+			resp.BadRequest(w, r, "surname is required")
 			return
 		}
 		if name == "" {
-			render.JSON(w, r, resp.Error("name is required"))
+			resp.BadRequest(w, r, "name is required")
 			return
 		}
 		if email == "" {
-			render.JSON(w, r, resp.Error("email is required"))
+			resp.BadRequest(w, r, "email is required")
 			return
 		}
 		if phoneNumber == "" {
-			render.JSON(w, r, resp.Error("phone_number is required"))
+			resp.BadRequest(w, r, "phone_number is required")
 			return
 		}
 
 		// Получаем необязательные поля
 		middleName := strings.TrimSpace(r.FormValue("middle_name"))
+		personnelNumber := strings.TrimSpace(r.FormValue("personnel_number"))
 		cabinet := strings.TrimSpace(r.FormValue("cabinet"))
 		position := strings.TrimSpace(r.FormValue("position"))
 		department := strings.TrimSpace(r.FormValue("department"))
@@ -121,19 +120,34 @@ func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCrea
 			birthDate, err = time.Parse("2006-01-02", birthDateStr)
 			if err != nil {
 				msg := "invalid birth_date format (use YYYY-MM-DD)"
-				log.Error(msg, sl.Err(err))
-				render.JSON(w, r, resp.Error(msg))
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.BadRequest(w, r, msg)
 				return
 			}
 		}
 
+		// Создавать работников могут редакторы института или отдела, в который он попадает
+		scope := policy.Scope{Institute: institute, Department: department}
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionWorkerCreate, scope) {
+			return
+		}
+
 		// Обрабатываем фотографию
 		var photo []byte
+		var thumbnails map[int][]byte
+		crop, err := parseCropForm(r)
+		if err != nil {
+			msg := err.Error()
+			log.WarnContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
 		file, header, err := r.FormFile("photo")
 		if err != nil && err != http.ErrMissingFile {
 			msg := "failed to get photo file"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
@@ -141,48 +155,51 @@ func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCrea
 			defer file.Close()
 
 			// Проверяем размер файла
-			if header.Size > maxPhotoSizeWithPhoto {
-				msg := fmt.Sprintf("photo size too large (max %d MB)", maxPhotoSizeWithPhoto/(1024*1024))
-				log.Warn(msg, slog.Int64("size", header.Size))
-				render.JSON(w, r, resp.Error(msg))
+			if header.Size > maxPhotoSize {
+				msg := errPhotoTooLarge.Error()
+				log.WarnContext(ctx, msg, slog.Int64("size", header.Size))
+				resp.PayloadTooLarge(w, r, msg)
+				return
+			}
+
+			// Читаем файл
+			photo, err = io.ReadAll(io.LimitReader(file, maxPhotoSize+1))
+			if err != nil {
+				msg := "failed to read photo file"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.BodyError(w, r, err, msg)
 				return
 			}
 
-			// Проверяем тип файла по Content-Type
+			// Без Content-Type от клиента тип берём по расширению файла
 			contentType := header.Header.Get("Content-Type")
-			if !allowedImageTypes[contentType] {
-				// Дополнительная проверка по расширению файла
-				ext := strings.ToLower(filepath.Ext(header.Filename))
-				validExt := false
-				switch ext {
-				case ".jpg", ".jpeg", ".png", ".gif", ".webp":
-					validExt = true
-				}
-
-				if !validExt {
-					msg := "invalid file type (allowed: jpeg, jpg, png, gif, webp)"
-					log.Warn(msg, slog.String("content_type", contentType), slog.String("filename", header.Filename))
-					render.JSON(w, r, resp.Error(msg))
-					return
-				}
+			if contentType == "" {
+				contentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(header.Filename)))
 			}
 
-			// Читаем файл
-			photo, err = io.ReadAll(file)
+			// Проверяем содержимое и перекодируем в JPEG без EXIF, заодно строим миниатюры
+			processed, err := processPhoto(photo, contentType, crop)
 			if err != nil {
-				msg := "failed to read photo file"
-				log.Error(msg, sl.Err(err))
-				render.JSON(w, r, resp.Error(msg))
+				msg := err.Error()
+				log.WarnContext(ctx, msg, slog.String("content_type", contentType), slog.String("filename", header.Filename))
+				photoProblem(w, r, err)
 				return
 			}
+			photo, thumbnails = processed.Original, processed.Thumbnails
 
-			log.Info("photo received",
+			log.InfoContext(ctx, "photo received",
 				slog.String("filename", header.Filename),
 				slog.Int64("size", header.Size),
 				slog.String("content_type", contentType),
 			)
 		}
 
+		// В институтах с модерацией работник создаётся без фото, а фото ждёт одобрения
+		var pendingPhoto []byte
+		if len(photo) > 0 && needsModeration(r, log, authorizer, moderation, scope) {
+			pendingPhoto, photo, thumbnails = photo, nil, nil
+		}
+
 		userID, err := userCreater.CreateUser(
 			ctx,
 			institute,
@@ -190,6 +207,7 @@ func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCrea
 			name,
 			middleName,
 			email,
+			personnelNumber,
 			phoneNumber,
 			cabinet,
 			position,
@@ -202,19 +220,36 @@ func CreateWithPhoto(ctx context.Context, log *slog.Logger, userCreater UserCrea
 
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
 			msg := "user already exists"
-			log.Warn(msg, slog.String("email", email))
-			render.JSON(w, r, resp.Error(msg))
+			log.WarnContext(ctx, msg, slog.String("email", email))
+			resp.Conflict(w, r, msg)
 			return
 		}
 
 		if err != nil {
 			msg := "failed to save user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully saved", slog.String("email", email), slog.Int("user_id", userID))
+		if len(thumbnails) > 0 {
+			// Не критично: недостающие миниатюры построятся при первом запросе, но без кадрирования
+			if err := userCreater.UpdateUserPhotoCrop(ctx, institute, email, crop, thumbnails); err != nil {
+				log.WarnContext(ctx, "failed to save photo thumbnails", slog.Int("user_id", userID), sl.Err(err))
+			}
+		}
+
+		if len(pendingPhoto) > 0 {
+			// Работник уже создан, поэтому ошибку очереди только логируем: фото можно загрузить заново
+			pendingID, err := userCreater.SubmitPendingPhoto(ctx, institute, email, pendingPhoto, crop, submitterID(r))
+			if err != nil {
+				log.ErrorContext(ctx, "failed to submit photo for moderation", slog.Int("user_id", userID), sl.Err(err))
+			} else {
+				log.InfoContext(ctx, "photo submitted for moderation", slog.Int("user_id", userID), slog.Int("pending_id", pendingID))
+			}
+		}
+
+		log.InfoContext(ctx, "user successfully saved", slog.String("email", email), slog.Int("user_id", userID))
 
 		createResponseOk(w, r, userID)
 	}
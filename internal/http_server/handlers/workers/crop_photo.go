@@ -0,0 +1,142 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type PhotoCropper interface {
+	UserGetter
+	GetUserPhoto(ctx context.Context, institute string, email string) ([]byte, error)
+	UpdateUserPhotoCrop(ctx context.Context, institute string, email string, crop *models.CropRect, thumbnails map[int][]byte) error
+}
+
+// CropPhoto меняет область кадрирования уже загруженной фотографии.
+// Оригинал не изменяется, миниатюры строятся заново.
+// @Summary Кадрировать фотографию работника
+// @Tags workers
+// @Accept json
+// @Produce json
+// @Param email path string true "Email работника"
+// @Param institute query string true "Институт"
+// @Param crop body models.CropRect true "Область кадрирования в пикселях оригинала"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /workers/{email}/photo/crop [put]
+func CropPhoto(log *slog.Logger, photoCropper PhotoCropper, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.crop_photo.CropPhoto"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		email, err := emailParam(r)
+		if err != nil {
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		// Как и замена фотографии без модерации, кадрирование доступно редакторам отдела работника
+		scope, ok := workerScope(w, r, log, photoCropper, institute, email)
+		if !ok {
+			return
+		}
+
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoWrite, scope) {
+			return
+		}
+
+		var crop models.CropRect
+		if err := render.DecodeJSON(r.Body, &crop); err != nil {
+			msg := "failed to decode request body"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		if err := validator.New().Struct(crop); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(ctx, "invalid request", sl.Err(err))
+			resp.Validation(w, r, validateErr)
+			return
+		}
+
+		photo, err := photoCropper.GetUserPhoto(ctx, institute, email)
+		if err != nil {
+			if err == storage.ErrUserNotFound {
+				msg := "user not found"
+				log.InfoContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to get user photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		if len(photo) == 0 {
+			msg := "user has no photo"
+			log.InfoContext(ctx, msg, slog.String("email", email))
+			resp.NotFound(w, r, msg)
+			return
+		}
+
+		thumbnails, err := imaging.Recrop(photo, cropRectangle(&crop))
+		if err != nil {
+			msg := imagingError(err).Error()
+			log.WarnContext(ctx, msg, sl.Err(err))
+			resp.Unprocessable(w, r, msg)
+			return
+		}
+
+		if err := photoCropper.UpdateUserPhotoCrop(ctx, institute, email, &crop, thumbnails); err != nil {
+			if err == storage.ErrUserNotFound {
+				msg := "user not found"
+				log.InfoContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to save photo crop"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "photo cropped",
+			slog.String("email", email),
+			slog.String("institute", institute),
+			slog.Any("crop", crop),
+		)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
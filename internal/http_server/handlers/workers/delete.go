@@ -7,6 +7,7 @@ import (
 	"telephone-book/internal/http_server/middleware"
 	"telephone-book/internal/lib/logger/sl"
 	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
@@ -21,6 +22,7 @@ type DeleteResponse struct {
 }
 
 type UserDeleter interface {
+	UserGetter
 	DeleteUser(
 		ctx context.Context,
 		institute string,
@@ -33,58 +35,62 @@ type UserDeleter interface {
 // @Tags workers
 // @Produce json
 // @Param institute query string true "Институт"
-// @Param email query string true "Email работника"
+// @Param email path string true "Email работника"
 // @Success 200 {object} DeleteResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Router /workers [delete]
-func Delete(ctx context.Context, log *slog.Logger, userDeleter UserDeleter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Router /workers/{email} [delete]
+func Delete(log *slog.Logger, userDeleter UserDeleter, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.delete.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		email := r.URL.Query().Get("email")
-		if email == "" {
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+		email, err := emailParam(r)
+		if err != nil {
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		scope, ok := workerScope(w, r, log, userDeleter, institute, email)
+		if !ok {
 			return
 		}
 
-		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			render.JSON(w, r, resp.Error("unauthorized: only admin can delete users"))
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionWorkerDelete, scope) {
 			return
 		}
 
-		err := userDeleter.DeleteUser(ctx, institute, email)
+		err = userDeleter.DeleteUser(ctx, institute, email)
 		if err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Info(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.InfoContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 
 			msg := "failed to delete user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully deleted",
+		log.InfoContext(ctx, "user successfully deleted",
 			slog.String("email", email),
 			slog.String("institute", institute))
 
@@ -4,9 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"strings"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 
 	middleware "telephone-book/internal/http_server/middleware"
@@ -22,6 +21,7 @@ type DeletePhotoResponse struct {
 }
 
 type PhotoDeleter interface {
+	UserGetter
 	DeleteUserPhoto(ctx context.Context, institute string, email string) error
 }
 
@@ -32,58 +32,48 @@ type PhotoDeleter interface {
 // @Param email path string true "Email работника"
 // @Param institute query string true "Институт"
 // @Success 200 {object} DeletePhotoResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Failure 403 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
 // @Router /workers/{email}/photo [delete]
-func DeletePhoto(ctx context.Context, log *slog.Logger, photoDeleter PhotoDeleter) http.HandlerFunc {
+func DeletePhoto(log *slog.Logger, photoDeleter PhotoDeleter, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.delete_photo.DeletePhoto"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		// Проверяем роль пользователя - только админы могут удалять фото
-		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			msg := "forbidden: only administrators can delete worker photos"
-			log.Warn(msg)
-			render.JSON(w, r, resp.Error(msg))
+		email, err := emailParam(r)
+		if err != nil {
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		// Получаем email из URL path, обходя проблему chi с точками в email
-		path := r.URL.Path
-		parts := strings.Split(path, "/")
-		if len(parts) < 4 { // /workers/{email}/photo
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+		// Получаем institute из query параметров
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		// Декодируем URL-encoded email
-		encodedEmail := parts[2] // /workers/{email}/photo
-		email, err := url.QueryUnescape(encodedEmail)
-		if err != nil {
-			msg := "invalid email format"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+		// Удалять фото могут редакторы отдела работника
+		scope, ok := workerScope(w, r, log, photoDeleter, institute, email)
+		if !ok {
 			return
 		}
 
-		// Получаем institute из query параметров
-		institute := r.URL.Query().Get("institute")
-		if institute == "" {
-			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoWrite, scope) {
 			return
 		}
 
-		log.Info("processing photo deletion request",
+		log.InfoContext(ctx, "processing photo deletion request",
 			slog.String("email", email),
 			slog.String("institute", institute))
 
@@ -92,17 +82,17 @@ func DeletePhoto(ctx context.Context, log *slog.Logger, photoDeleter PhotoDelete
 		if err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Warn(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.WarnContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 			msg := "failed to delete user photo"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("photo deleted successfully",
+		log.InfoContext(ctx, "photo deleted successfully",
 			slog.String("email", email),
 			slog.String("institute", institute))
 
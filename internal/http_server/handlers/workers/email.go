@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+var (
+	errEmailMissing = errors.New("email not specified")
+	errEmailInvalid = errors.New("invalid email format")
+)
+
+// emailParam email работника из пути ({email}). Если адрес — последний сегмент пути,
+// chi URLFormat принимает домен верхнего уровня за расширение и отрезает его; возвращаем на место
+func emailParam(r *http.Request) (string, error) {
+	email := chi.URLParam(r, "email")
+	if email == "" {
+		return "", errEmailMissing
+	}
+	if format, _ := r.Context().Value(chimw.URLFormatCtxKey).(string); format != "" {
+		email += "." + format
+	}
+
+	email, err := url.QueryUnescape(email)
+	if err != nil {
+		return "", errEmailInvalid
+	}
+	return email, nil
+}
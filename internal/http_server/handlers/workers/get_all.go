@@ -34,13 +34,14 @@ type AllUsersGetter interface {
 // @Param department query string false "Отдел"
 // @Param section query string false "Секция"
 // @Success 200 {object} AllUsersResponse
-// @Failure 400 {object} response.Response
-// @Router /workers/all [post]
-func GetAll(ctx context.Context, log *slog.Logger, allUsersGetter AllUsersGetter) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Router /workers [get]
+func GetAll(log *slog.Logger, allUsersGetter AllUsersGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.read.GetAll"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -48,8 +49,8 @@ func GetAll(ctx context.Context, log *slog.Logger, allUsersGetter AllUsersGetter
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -67,12 +68,12 @@ func GetAll(ctx context.Context, log *slog.Logger, allUsersGetter AllUsersGetter
 		users, err := allUsersGetter.GetAllUsers(ctx, institute, department, section)
 		if err != nil {
 			msg := "failed to get users"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("users retrieved successfully", slog.Int("count", len(users)))
+		log.InfoContext(ctx, "users retrieved successfully", slog.Int("count", len(users)))
 
 		getResponseOk(w, r, users)
 	}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"strings"
 	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
 	"telephone-book/internal/storage"
@@ -37,44 +35,33 @@ type UserGetter interface {
 // @Param email path string true "Email работника"
 // @Param institute query string true "Институт"
 // @Success 200 {object} GetResponse
-// @Failure 400 {object} response.Response
+// @Failure 400 {object} response.Problem
 // @Router /workers/{email} [get]
-func GetByEmail(ctx context.Context, log *slog.Logger, userGetter UserGetter) http.HandlerFunc {
+func GetByEmail(log *slog.Logger, userGetter UserGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.read.GetByEmail"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		// Получаем email из URL path, обходя проблему chi с точками в email
-		path := r.URL.Path
-		parts := strings.Split(path, "/")
-		if len(parts) < 3 {
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
-			return
-		}
-
-		// Декодируем URL-encoded email
-		encodedEmail := parts[2] // /workers/{email}
-		email, err := url.QueryUnescape(encodedEmail)
+		email, err := emailParam(r)
 		if err != nil {
-			msg := "invalid email format"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		log.Info("processing request", slog.String("email", email))
+		log.InfoContext(ctx, "processing request", slog.String("email", email))
 
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -82,18 +69,18 @@ func GetByEmail(ctx context.Context, log *slog.Logger, userGetter UserGetter) ht
 		if err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Info(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.InfoContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 
 			msg := "failed to get user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user retrieved successfully", slog.String("email", email))
+		log.InfoContext(ctx, "user retrieved successfully", slog.String("email", email))
 
 		render.JSON(w, r, GetResponse{
 			Status: resp.OK().Status,
@@ -6,62 +6,69 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"strings"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
 	"telephone-book/internal/lib/logger/sl"
 	"telephone-book/internal/storage"
 	"time"
 
 	resp "telephone-book/internal/lib/response"
 
+	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
 )
 
+type ThumbnailGetter interface {
+	GetUserByID(ctx context.Context, institute string, id int) (models.User, error)
+	GetUserPhotoByID(ctx context.Context, institute string, id int) ([]byte, error)
+	GetUserPhotoThumbnail(ctx context.Context, institute string, id int, size int) ([]byte, error)
+	SaveUserPhotoThumbnails(ctx context.Context, institute string, id int, thumbnails map[int][]byte) error
+}
+
 // GetPhoto возвращает фотографию пользователя
 // @Summary Получить фотографию пользователя
 // @Tags workers
-// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Produce image/jpeg,image/png,image/gif,image/webp,image/svg+xml
 // @Param email path string true "Email пользователя"
 // @Param institute query string true "Институт"
+// @Param fallback query string false "initials — аватар с инициалами, если фотографии нет"
+// @Param format query string false "Формат аватара: svg или png" default(svg)
 // @Success 200 {file} binary "Фотография пользователя"
-// @Failure 400 {object} response.Response
-// @Failure 404 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
 // @Router /workers/{email}/photo [get]
-func GetPhoto(ctx context.Context, log *slog.Logger, userGetter UserGetter) http.HandlerFunc {
+func GetPhoto(log *slog.Logger, userGetter UserGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.get_photo.GetPhoto"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		// Получаем email из URL path, обходя проблему chi с точками в email
-		path := r.URL.Path
-		parts := strings.Split(path, "/")
-		if len(parts) < 4 { // /workers/{email}/photo
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
-			return
-		}
-
-		// Декодируем URL-encoded email
-		encodedEmail := parts[2] // /workers/{email}/photo
-		email, err := url.QueryUnescape(encodedEmail)
+		email, err := emailParam(r)
 		if err != nil {
-			msg := "invalid email format"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		avatarOpts, err := parseAvatarOptions(r)
+		if err != nil {
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -69,90 +76,212 @@ func GetPhoto(ctx context.Context, log *slog.Logger, userGetter UserGetter) http
 		if err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Info(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.InfoContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 
 			msg := "failed to get user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		if len(user) == 0 && avatarOpts.enabled {
+			worker, err := userGetter.GetUserByEmail(ctx, institute, email)
+			if err != nil {
+				msg := "failed to get user"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+
+			writeAvatar(w, r, log, worker, avatarOpts)
 			return
 		}
 
 		if len(user) == 0 {
 			msg := "user has no photo"
-			log.Info(msg, slog.String("email", email))
-			render.JSON(w, r, resp.Error(msg))
+			log.InfoContext(ctx, msg, slog.String("email", email))
+			resp.NotFound(w, r, msg)
+			return
+		}
+
+		writePhoto(w, r, log, user)
+
+		log.InfoContext(ctx, "photo served successfully",
+			slog.String("email", email),
+			slog.Int("size", len(user)),
+		)
+	}
+}
+
+// GetPhotoByID возвращает фотографию работника по id, с size — квадратную миниатюру.
+// Размер округляется вверх до ближайшей готовой миниатюры (64, 256, 512).
+// @Summary Получить фотографию или миниатюру по id
+// @Tags workers
+// @Produce image/jpeg,image/png,image/gif,image/webp,image/svg+xml
+// @Param id path int true "ID работника"
+// @Param institute query string true "Институт"
+// @Param size query int false "Размер стороны миниатюры в пикселях"
+// @Param fallback query string false "initials — аватар с инициалами, если фотографии нет"
+// @Param format query string false "Формат аватара: svg или png" default(svg)
+// @Success 200 {file} binary "Фотография пользователя"
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /workers/{id}/photo [get]
+func GetPhotoByID(log *slog.Logger, thumbnailGetter ThumbnailGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.get_photo.GetPhotoByID"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			msg := "invalid worker id"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		// Определяем тип контента по первым байтам изображения
-		contentType := detectImageType(user)
-		if contentType == "" {
-			contentType = "application/octet-stream"
+		size := 0
+		if value := r.URL.Query().Get("size"); value != "" {
+			requested, err := strconv.Atoi(value)
+			if err != nil || requested <= 0 {
+				msg := "invalid size parameter"
+				log.ErrorContext(ctx, msg, slog.String("size", value))
+				resp.BadRequest(w, r, msg)
+				return
+			}
+			size = imaging.ThumbnailSize(requested)
 		}
 
-		// Генерируем ETag для кеширования
-		hash := md5.Sum(user)
-		etag := fmt.Sprintf(`"%x"`, hash)
+		avatarOpts, err := parseAvatarOptions(r)
+		if err != nil {
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
 
-		// Проверяем If-None-Match для кеширования
-		if match := r.Header.Get("If-None-Match"); match == etag {
-			w.WriteHeader(http.StatusNotModified)
+		var photo []byte
+		if size > 0 {
+			photo, err = thumbnailGetter.GetUserPhotoThumbnail(ctx, institute, id, size)
+			if err == storage.ErrThumbnailNotFound {
+				photo, err = buildThumbnail(ctx, log, thumbnailGetter, institute, id, size)
+			}
+		} else {
+			photo, err = thumbnailGetter.GetUserPhotoByID(ctx, institute, id)
+		}
+		if err != nil {
+			if err == storage.ErrUserNotFound {
+				msg := "user not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+
+			msg := "failed to get user photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		// Устанавливаем заголовки
-		w.Header().Set("Content-Type", contentType)
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(user)))
-		w.Header().Set("ETag", etag)
-		w.Header().Set("Cache-Control", "public, max-age=3600") // Кеш на 1 час
-		w.Header().Set("Last-Modified", time.Now().Format(http.TimeFormat))
+		if len(photo) == 0 && avatarOpts.enabled {
+			worker, err := thumbnailGetter.GetUserByID(ctx, institute, id)
+			if err != nil {
+				msg := "failed to get user"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
 
-		// Отправляем изображение
-		w.WriteHeader(http.StatusOK)
-		w.Write(user)
+			writeAvatar(w, r, log, worker, avatarOpts)
+			return
+		}
 
-		log.Info("photo served successfully",
-			slog.String("email", email),
-			slog.Int("size", len(user)),
-			slog.String("content_type", contentType),
-		)
+		if len(photo) == 0 {
+			msg := "user has no photo"
+			log.InfoContext(ctx, msg, slog.Int("id", id))
+			resp.NotFound(w, r, msg)
+			return
+		}
+
+		writePhoto(w, r, log, photo)
 	}
 }
 
-// detectImageType определяет тип изображения по магическим байтам
-func detectImageType(data []byte) string {
-	if len(data) < 12 {
-		return ""
+// buildThumbnail строит недостающую миниатюру из оригинала и кэширует её,
+// например для фотографий, загруженных до появления миниатюр
+func buildThumbnail(ctx context.Context, log *slog.Logger, thumbnailGetter ThumbnailGetter, institute string, id int, size int) ([]byte, error) {
+	original, err := thumbnailGetter.GetUserPhotoByID(ctx, institute, id)
+	if err != nil || len(original) == 0 {
+		return nil, err
 	}
 
-	// JPEG
-	if len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF {
-		return "image/jpeg"
+	worker, err := thumbnailGetter.GetUserByID(ctx, institute, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// PNG
-	if len(data) >= 8 &&
-		data[0] == 0x89 && data[1] == 0x50 && data[2] == 0x4E && data[3] == 0x47 &&
-		data[4] == 0x0D && data[5] == 0x0A && data[6] == 0x1A && data[7] == 0x0A {
-		return "image/png"
+	thumbnail, err := imaging.Resize(original, size, cropRectangle(worker.PhotoCrop))
+	if err != nil {
+		return nil, err
 	}
 
-	// GIF
-	if len(data) >= 6 &&
-		((data[0] == 0x47 && data[1] == 0x49 && data[2] == 0x46 && data[3] == 0x38 && data[4] == 0x37 && data[5] == 0x61) ||
-			(data[0] == 0x47 && data[1] == 0x49 && data[2] == 0x46 && data[3] == 0x38 && data[4] == 0x39 && data[5] == 0x61)) {
-		return "image/gif"
+	if err := thumbnailGetter.SaveUserPhotoThumbnails(ctx, institute, id, map[int][]byte{size: thumbnail}); err != nil {
+		log.WarnContext(ctx, "failed to cache photo thumbnail", slog.Int("id", id), slog.Int("size", size), sl.Err(err))
 	}
 
-	// WebP
-	if len(data) >= 12 &&
-		data[0] == 0x52 && data[1] == 0x49 && data[2] == 0x46 && data[3] == 0x46 &&
-		data[8] == 0x57 && data[9] == 0x45 && data[10] == 0x42 && data[11] == 0x50 {
-		return "image/webp"
+	return thumbnail, nil
+}
+
+// writePhoto отдаёт фотографию, тип определяется по содержимому
+func writePhoto(w http.ResponseWriter, r *http.Request, log *slog.Logger, photo []byte) {
+	contentType := imaging.DetectType(photo)
+	if contentType == "" {
+		contentType = "application/octet-stream"
 	}
 
-	return ""
+	writeImage(w, r, log, photo, contentType, "public, max-age=3600") // Кеш на 1 час
+}
+
+// writeImage отдаёт изображение с ETag для кеширования в браузере
+func writeImage(w http.ResponseWriter, r *http.Request, log *slog.Logger, data []byte, contentType string, cacheControl string) {
+	// Генерируем ETag для кеширования
+	hash := md5.Sum(data)
+	etag := fmt.Sprintf(`"%x"`, hash)
+
+	// Проверяем If-None-Match для кеширования
+	if match := r.Header.Get("If-None-Match"); match == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", cacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Устанавливаем заголовки
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Last-Modified", time.Now().Format(http.TimeFormat))
+
+	// Отправляем изображение
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.WarnContext(r.Context(), "failed to write image", sl.Err(err))
+	}
 }
@@ -0,0 +1,302 @@
+package workers
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	maxPhotoArchiveSize  = 200 * 1024 * 1024 // 200 MB
+	maxPhotoArchiveFiles = 2000
+)
+
+type PhotoImportFile struct {
+	// Имя файла в архиве
+	File string `json:"file"`
+	// Email работника, которому подошла фотография
+	Email string `json:"email,omitempty"`
+	// Причина, по которой файл не загружен
+	Reason string `json:"reason,omitempty"`
+	// Номер в очереди модерации, если фото ждёт одобрения
+	PendingID int `json:"pending_id,omitempty"`
+}
+
+type ImportPhotosResponse struct {
+	// Статус ответа: Ok или Error. Пример: "Ok"
+	Status string `json:"status"`
+	// Сообщение об ошибке, если есть. Пример: "invalid request"
+	Error string `json:"error,omitempty"`
+	// Загруженные фотографии
+	Matched []PhotoImportFile `json:"matched"`
+	// Файлы, для которых не нашлось работника
+	Unmatched []PhotoImportFile `json:"unmatched"`
+	// Файлы, не прошедшие проверку
+	Rejected []PhotoImportFile `json:"rejected"`
+}
+
+type BulkPhotoImporter interface {
+	PendingPhotoSubmitter
+	GetUserEmailByPersonnelNumber(ctx context.Context, institute string, personnelNumber string) (string, error)
+	GetUserPhoto(ctx context.Context, institute string, email string) ([]byte, error)
+	UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, thumbnails map[int][]byte) error
+}
+
+// ImportPhotos загружает фотографии из ZIP архива.
+// Файлы называются по email (ivanov@giredmet.ru.jpg) или табельному номеру (001234.png).
+// В институтах с модерацией фото от не-администраторов попадают в очередь на одобрение.
+// @Summary Массовая загрузка фотографий из ZIP
+// @Tags workers
+// @Accept multipart/form-data
+// @Produce json
+// @Param institute query string true "Институт"
+// @Param overwrite query bool false "Заменять существующие фотографии (только для администраторов)"
+// @Param archive formData file true "ZIP архив с фотографиями (max 200MB)"
+// @Success 200 {object} ImportPhotosResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Router /photos/import [post]
+func ImportPhotos(log *slog.Logger, photoImporter BulkPhotoImporter, authorizer middleware.Authorizer, moderation PhotoModeration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.import_photos.ImportPhotos"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		role := middleware.GetRole(r.Context(), log)
+		if role == middleware.RoleGuest {
+			msg := "unauthorized: only authenticated users can upload worker photos"
+			log.WarnContext(ctx, msg)
+			resp.Unauthorized(w, r, msg)
+			return
+		}
+
+		institute := r.URL.Query().Get("institute")
+		if institute == "" {
+			msg := "institute not specified"
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		overwrite := false
+		if value := r.URL.Query().Get("overwrite"); value != "" {
+			var err error
+			overwrite, err = strconv.ParseBool(value)
+			if err != nil {
+				msg := "invalid overwrite parameter"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.BadRequest(w, r, msg)
+				return
+			}
+		}
+
+		// Архив затрагивает весь институт, поэтому права проверяются на институт целиком
+		scope := policy.Scope{Institute: institute}
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoSubmit, scope) {
+			return
+		}
+
+		// Заменять существующие фото может только тот, кто может менять фото без модерации
+		if overwrite && !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoWrite, scope) {
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoArchiveSize+1024*1024)
+
+		// Большие архивы multipart сохраняет во временный файл
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			msg := "failed to parse multipart form"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		file, header, err := r.FormFile("archive")
+		if err != nil {
+			msg := "archive file is required"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+		defer file.Close()
+
+		archive, err := zip.NewReader(file, header.Size)
+		if err != nil {
+			msg := "invalid zip archive"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		if len(archive.File) > maxPhotoArchiveFiles {
+			msg := fmt.Sprintf("too many files in archive (max %d)", maxPhotoArchiveFiles)
+			log.WarnContext(ctx, msg, slog.Int("files", len(archive.File)))
+			resp.PayloadTooLarge(w, r, msg)
+			return
+		}
+
+		// Фото от не-администраторов в институтах с модерацией уходят в очередь
+		moderated := needsModeration(r, log, authorizer, moderation, scope)
+
+		log = log.With(slog.String("institute", institute), slog.Bool("overwrite", overwrite), slog.Bool("moderated", moderated))
+
+		report := ImportPhotosResponse{
+			Status:    resp.OK().Status,
+			Matched:   []PhotoImportFile{},
+			Unmatched: []PhotoImportFile{},
+			Rejected:  []PhotoImportFile{},
+		}
+
+		for _, entry := range archive.File {
+			name := entry.Name
+			base := path.Base(name)
+
+			// Пропускаем каталоги и служебные файлы архиваторов
+			if entry.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+				continue
+			}
+
+			ext := strings.ToLower(path.Ext(base))
+			key := strings.TrimSpace(strings.TrimSuffix(base, path.Ext(base)))
+
+			if entry.UncompressedSize64 > maxPhotoSize {
+				report.Rejected = append(report.Rejected, PhotoImportFile{File: name, Reason: errPhotoTooLarge.Error()})
+				continue
+			}
+
+			contentType := mime.TypeByExtension(ext)
+			if !imaging.AllowedTypes[imaging.NormalizeType(contentType)] {
+				report.Rejected = append(report.Rejected, PhotoImportFile{File: name, Reason: errPhotoType.Error()})
+				continue
+			}
+
+			email, err := matchPhotoOwner(ctx, photoImporter, institute, key)
+			if err != nil {
+				if err == storage.ErrUserNotFound {
+					report.Unmatched = append(report.Unmatched, PhotoImportFile{File: name, Reason: "user not found"})
+					continue
+				}
+				msg := "failed to match photo"
+				log.ErrorContext(ctx, msg, slog.String("file", name), sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+
+			if !overwrite {
+				existingPhoto, err := photoImporter.GetUserPhoto(ctx, institute, email)
+				if err != nil && err != storage.ErrUserNotFound {
+					msg := "failed to check existing photo"
+					log.ErrorContext(ctx, msg, sl.Err(err))
+					resp.FromError(w, r, err, msg)
+					return
+				}
+				if len(existingPhoto) > 0 {
+					report.Rejected = append(report.Rejected, PhotoImportFile{File: name, Email: email, Reason: "user already has a photo"})
+					continue
+				}
+			}
+
+			photo, err := readZipEntry(entry, maxPhotoSize)
+			if err != nil {
+				report.Rejected = append(report.Rejected, PhotoImportFile{File: name, Email: email, Reason: err.Error()})
+				continue
+			}
+
+			processed, err := processPhoto(photo, contentType, nil)
+			if err != nil {
+				report.Rejected = append(report.Rejected, PhotoImportFile{File: name, Email: email, Reason: err.Error()})
+				continue
+			}
+
+			if moderated {
+				pendingID, err := photoImporter.SubmitPendingPhoto(ctx, institute, email, processed.Original, nil, submitterID(r))
+				if err != nil {
+					if err == storage.ErrUserNotFound {
+						report.Unmatched = append(report.Unmatched, PhotoImportFile{File: name, Reason: "user not found"})
+						continue
+					}
+					msg := "failed to submit photo for moderation"
+					log.ErrorContext(ctx, msg, slog.String("email", email), sl.Err(err))
+					resp.FromError(w, r, err, msg)
+					return
+				}
+
+				report.Matched = append(report.Matched, PhotoImportFile{File: name, Email: email, PendingID: pendingID})
+				continue
+			}
+
+			if err := photoImporter.UpdateUserPhoto(ctx, institute, email, processed.Original, nil, processed.Thumbnails); err != nil {
+				if err == storage.ErrUserNotFound {
+					report.Unmatched = append(report.Unmatched, PhotoImportFile{File: name, Reason: "user not found"})
+					continue
+				}
+				msg := "failed to upload user photo"
+				log.ErrorContext(ctx, msg, slog.String("email", email), sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+
+			report.Matched = append(report.Matched, PhotoImportFile{File: name, Email: email})
+		}
+
+		log.InfoContext(ctx, "photo archive processed",
+			slog.Int("matched", len(report.Matched)),
+			slog.Int("unmatched", len(report.Unmatched)),
+			slog.Int("rejected", len(report.Rejected)),
+		)
+
+		render.JSON(w, r, report)
+	}
+}
+
+// matchPhotoOwner возвращает email работника по имени файла: email или табельный номер
+func matchPhotoOwner(ctx context.Context, photoImporter BulkPhotoImporter, institute string, key string) (string, error) {
+	// Существование работника по email проверит UpdateUserPhoto
+	if strings.Contains(key, "@") {
+		return key, nil
+	}
+
+	return photoImporter.GetUserEmailByPersonnelNumber(ctx, institute, key)
+}
+
+// readZipEntry читает файл архива, не доверяя заявленному в заголовке размеру
+func readZipEntry(entry *zip.File, limit int64) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("photo file is too large (max %dMB)", limit/(1024*1024))
+	}
+
+	return data, nil
+}
@@ -0,0 +1,284 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Ответ со списком фотографий на модерации
+type PendingPhotosResponse struct {
+	Status string                `json:"status"`
+	Error  string                `json:"error,omitempty"`
+	Photos []models.PendingPhoto `json:"photos"`
+}
+
+type PendingPhotosGetter interface {
+	GetPendingPhotos(ctx context.Context, institute string) ([]models.PendingPhoto, error)
+}
+
+type PendingPhotoImageGetter interface {
+	GetPendingPhotoImage(ctx context.Context, institute string, id int) ([]byte, *models.CropRect, error)
+}
+
+type PendingPhotoApprover interface {
+	PendingPhotoImageGetter
+	ApprovePendingPhoto(ctx context.Context, institute string, id int, thumbnails map[int][]byte) error
+}
+
+type PendingPhotoRejecter interface {
+	RejectPendingPhoto(ctx context.Context, institute string, id int) error
+}
+
+// GetPendingPhotos возвращает фотографии, ожидающие одобрения
+// @Summary Очередь фотографий на модерации
+// @Tags workers
+// @Produce json
+// @Param institute query string true "Институт"
+// @Success 200 {object} PendingPhotosResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Router /photos/pending [get]
+func GetPendingPhotos(log *slog.Logger, pendingPhotosGetter PendingPhotosGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.pending_photos.GetPendingPhotos"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute, ok := moderationRequest(w, r, log, authorizer)
+		if !ok {
+			return
+		}
+
+		photos, err := pendingPhotosGetter.GetPendingPhotos(ctx, institute)
+		if err != nil {
+			msg := "failed to get pending photos"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		render.JSON(w, r, PendingPhotosResponse{
+			Status: resp.OK().Status,
+			Photos: photos,
+		})
+	}
+}
+
+// GetPendingPhoto возвращает фотографию из очереди модерации
+// @Summary Фотография на модерации
+// @Tags workers
+// @Produce image/jpeg
+// @Param id path int true "Номер в очереди модерации"
+// @Param institute query string true "Институт"
+// @Success 200 {file} binary
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /photos/pending/{id} [get]
+func GetPendingPhoto(log *slog.Logger, imageGetter PendingPhotoImageGetter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.pending_photos.GetPendingPhoto"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute, ok := moderationRequest(w, r, log, authorizer)
+		if !ok {
+			return
+		}
+
+		id, ok := pendingPhotoID(w, r, log)
+		if !ok {
+			return
+		}
+
+		photo, _, err := imageGetter.GetPendingPhotoImage(ctx, institute, id)
+		if err != nil {
+			if err == storage.ErrPendingPhotoNotFound {
+				msg := "pending photo not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to get pending photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		// Решение по фото может измениться в любой момент, поэтому не кешируем
+		writeImage(w, r, log, photo, imaging.ContentType, "private, no-store")
+	}
+}
+
+// ApprovePhoto одобряет фотографию: она заменяет текущую фотографию работника
+// @Summary Одобрить фотографию
+// @Tags workers
+// @Produce json
+// @Param id path int true "Номер в очереди модерации"
+// @Param institute query string true "Институт"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /photos/pending/{id}/approve [post]
+func ApprovePhoto(log *slog.Logger, approver PendingPhotoApprover, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.pending_photos.ApprovePhoto"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute, ok := moderationRequest(w, r, log, authorizer)
+		if !ok {
+			return
+		}
+
+		id, ok := pendingPhotoID(w, r, log)
+		if !ok {
+			return
+		}
+
+		photo, crop, err := approver.GetPendingPhotoImage(ctx, institute, id)
+		if err != nil {
+			if err == storage.ErrPendingPhotoNotFound {
+				msg := "pending photo not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to get pending photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		// Фото уже проверено и перекодировано при загрузке, осталось построить миниатюры
+		thumbnails, err := imaging.Recrop(photo, cropRectangle(crop))
+		if err != nil {
+			msg := "failed to build photo thumbnails"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		if err := approver.ApprovePendingPhoto(ctx, institute, id, thumbnails); err != nil {
+			if err == storage.ErrPendingPhotoNotFound {
+				msg := "pending photo not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to approve photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "photo approved", slog.String("institute", institute), slog.Int("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// RejectPhoto отклоняет фотографию; у работника остаётся прежнее фото
+// @Summary Отклонить фотографию
+// @Tags workers
+// @Produce json
+// @Param id path int true "Номер в очереди модерации"
+// @Param institute query string true "Институт"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /photos/pending/{id}/reject [post]
+func RejectPhoto(log *slog.Logger, rejecter PendingPhotoRejecter, authorizer middleware.Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workers.pending_photos.RejectPhoto"
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("operation", op),
+			slog.String("request_id", chimw.GetReqID(r.Context())),
+		)
+
+		institute, ok := moderationRequest(w, r, log, authorizer)
+		if !ok {
+			return
+		}
+
+		id, ok := pendingPhotoID(w, r, log)
+		if !ok {
+			return
+		}
+
+		if err := rejecter.RejectPendingPhoto(ctx, institute, id); err != nil {
+			if err == storage.ErrPendingPhotoNotFound {
+				msg := "pending photo not found"
+				log.InfoContext(ctx, msg, slog.Int("id", id))
+				resp.NotFound(w, r, msg)
+				return
+			}
+			msg := "failed to reject photo"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		log.InfoContext(ctx, "photo rejected", slog.String("institute", institute), slog.Int("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// moderationRequest проверяет право модерировать фото института и возвращает институт
+func moderationRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, authorizer middleware.Authorizer) (string, bool) {
+	institute := r.URL.Query().Get("institute")
+	if institute == "" {
+		msg := "institute not specified"
+		log.ErrorContext(r.Context(), msg)
+		resp.BadRequest(w, r, msg)
+		return "", false
+	}
+
+	if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoModerate, policy.Scope{Institute: institute}) {
+		return "", false
+	}
+
+	return institute, true
+}
+
+func pendingPhotoID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		msg := "invalid pending photo id"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.BadRequest(w, r, msg)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,160 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+	"telephone-book/internal/storage"
+
+	middleware "telephone-book/internal/http_server/middleware"
+	resp "telephone-book/internal/lib/response"
+)
+
+// Общие ограничения для всех способов загрузки фотографий
+const maxPhotoSize = imaging.MaxFileSize
+
+// Ошибки проверки фотографии; текст возвращается клиенту
+var (
+	errPhotoTooLarge     = fmt.Errorf("photo file is too large (max %dMB)", maxPhotoSize/(1024*1024))
+	errPhotoType         = errors.New("unsupported image type (allowed: jpeg, png, gif, webp)")
+	errPhotoTypeMismatch = errors.New("file content does not match declared image type")
+	errPhotoInvalid      = errors.New("file content is not a valid image")
+	errPhotoDimensions   = fmt.Errorf("image dimensions are too large (max %dx%d)", imaging.MaxDimension, imaging.MaxDimension)
+	errPhotoCrop         = fmt.Errorf("crop area must be inside the image and at least %dx%d", imaging.MinCropSide, imaging.MinCropSide)
+	errPhotoCropFields   = errors.New("crop_x, crop_y, crop_width and crop_height must be non-negative integers and set together")
+)
+
+// PhotoModeration определяет институты, где фото от не-администраторов ждут одобрения
+type PhotoModeration interface {
+	PhotoModerationEnabled(institute string) bool
+}
+
+type PendingPhotoSubmitter interface {
+	SubmitPendingPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, submittedBy int64) (int, error)
+}
+
+// needsModeration сообщает, что фото нужно отправить в очередь, а не сразу показывать:
+// модерация включена, а у пользователя нет права менять фото работников scope
+func needsModeration(r *http.Request, log *slog.Logger, authorizer middleware.Authorizer, moderation PhotoModeration, scope policy.Scope) bool {
+	return moderation.PhotoModerationEnabled(scope.Institute) && !middleware.Can(r, log, authorizer, policy.ActionPhotoWrite, scope)
+}
+
+// submitterID id пользователя SSO, отправившего фото; 0 если неизвестен
+func submitterID(r *http.Request) int64 {
+	id, _ := middleware.GetUserID(r.Context())
+	return id
+}
+
+// workerScope находит работника и возвращает его институт и отдел для проверки прав.
+// При ошибке сам отвечает клиенту; false — обработку нужно прервать.
+func workerScope(w http.ResponseWriter, r *http.Request, log *slog.Logger, userGetter UserGetter, institute string, email string) (policy.Scope, bool) {
+	user, err := userGetter.GetUserByEmail(r.Context(), institute, email)
+	if err != nil {
+		if err == storage.ErrUserNotFound {
+			msg := "user not found"
+			log.WarnContext(r.Context(), msg, slog.String("email", email))
+			resp.NotFound(w, r, msg)
+			return policy.Scope{}, false
+		}
+		msg := "failed to get user"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.FromError(w, r, err, msg)
+		return policy.Scope{}, false
+	}
+
+	return policy.Scope{Institute: institute, Department: user.Department}, true
+}
+
+// processPhoto проверяет загруженную фотографию и перекодирует её в JPEG без метаданных.
+// declaredType — Content-Type от клиента или по расширению файла, пустой если неизвестен.
+// crop задаёт область для миниатюр, nil — кадрирование по умолчанию.
+func processPhoto(data []byte, declaredType string, crop *models.CropRect) (imaging.Photo, error) {
+	if len(data) > maxPhotoSize {
+		return imaging.Photo{}, errPhotoTooLarge
+	}
+
+	sniffed := imaging.DetectType(data)
+	if sniffed == "" {
+		return imaging.Photo{}, errPhotoType
+	}
+
+	// application/octet-stream отправляют клиенты, которые не знают тип файла
+	if declared := imaging.NormalizeType(declaredType); declared != "" && declared != "application/octet-stream" && declared != sniffed {
+		return imaging.Photo{}, errPhotoTypeMismatch
+	}
+
+	// Перекодирование отбрасывает EXIF и всё, что приклеено к файлу после данных изображения
+	photo, err := imaging.Process(data, cropRectangle(crop))
+	if err != nil {
+		return imaging.Photo{}, imagingError(err)
+	}
+
+	return photo, nil
+}
+
+// photoProblem отвечает на ошибку проверки фото: слишком большой файл — 413, негодное изображение — 422
+func photoProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errPhotoTooLarge) {
+		resp.PayloadTooLarge(w, r, err.Error())
+		return
+	}
+	resp.Unprocessable(w, r, err.Error())
+}
+
+// imagingError переводит ошибку обработки изображения в сообщение для клиента
+func imagingError(err error) error {
+	switch {
+	case errors.Is(err, imaging.ErrImageTooLarge):
+		return errPhotoDimensions
+	case errors.Is(err, imaging.ErrInvalidCrop):
+		return errPhotoCrop
+	default:
+		return errPhotoInvalid
+	}
+}
+
+// parseCropForm читает необязательные поля crop_x, crop_y, crop_width, crop_height из формы загрузки
+func parseCropForm(r *http.Request) (*models.CropRect, error) {
+	names := []string{"crop_x", "crop_y", "crop_width", "crop_height"}
+
+	values := make([]int, len(names))
+	set := 0
+	for i, name := range names {
+		value := r.FormValue(name)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, errPhotoCropFields
+		}
+		values[i] = n
+		set++
+	}
+
+	switch set {
+	case 0:
+		return nil, nil
+	case len(names):
+		return &models.CropRect{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+	default:
+		return nil, errPhotoCropFields
+	}
+}
+
+func cropRectangle(crop *models.CropRect) *image.Rectangle {
+	if crop == nil {
+		return nil
+	}
+	rect := image.Rect(crop.X, crop.Y, crop.X+crop.Width, crop.Y+crop.Height)
+	return &rect
+}
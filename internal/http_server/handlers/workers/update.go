@@ -7,19 +7,19 @@ import (
 	"net/http"
 	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 	"time"
 
 	middleware "telephone-book/internal/http_server/middleware"
 	resp "telephone-book/internal/lib/response"
 
+	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 )
 
-const maxPhotoSize = 5 * 1024 * 1024 // 5 MB
-
 type UpdateRequest struct {
 	Institute   string    `json:"institute" validate:"required"`
 	OldEmail    string    `json:"old_email" validate:"required,email"`
@@ -71,24 +71,25 @@ type UserUpdater interface {
 // @Accept json
 // @Produce json
 // @Param institute query string true "Институт"
-// @Param email query string true "Email работника"
+// @Param email path string true "Email работника"
 // @Param worker body UpdateRequest true "Новые данные работника"
 // @Success 200 {object} UpdateResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Router /workers [put]
-func Update(ctx context.Context, log *slog.Logger, userUpdater UserUpdater) http.HandlerFunc {
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Router /workers/{email} [put]
+func Update(log *slog.Logger, userUpdater UserUpdater, authorizer middleware.Authorizer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.update.New"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
 		role := middleware.GetRole(r.Context(), log)
 		if role == middleware.RoleGuest {
-			render.JSON(w, r, resp.Error("unauthorized: only authenticated users can update workers"))
+			resp.Unauthorized(w, r, "unauthorized: only authenticated users can update workers")
 			return
 		}
 
@@ -97,19 +98,29 @@ func Update(ctx context.Context, log *slog.Logger, userUpdater UserUpdater) http
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
 			msg := "failed to decode request body"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(ctx, "request body decoded", slog.String("institute", req.Institute), slog.String("email", req.Email))
+
+		// В /api/v1 работник задаётся в пути, в устаревшем PUT /workers — полем old_email
+		if chi.URLParam(r, "email") != "" {
+			if req.OldEmail, err = emailParam(r); err != nil {
+				msg := err.Error()
+				log.ErrorContext(ctx, msg)
+				resp.BadRequest(w, r, msg)
+				return
+			}
+		}
 
 		// Получаем institute и oldEmail из тела запроса
 		institute := req.Institute
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -117,27 +128,37 @@ func Update(ctx context.Context, log *slog.Logger, userUpdater UserUpdater) http
 		oldEmail := req.OldEmail
 		if oldEmail == "" {
 			msg := "old_email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			msg := "invalid request"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.Validation(w, r, validateErr)
 			return
 		}
 
-		// Только admin может изменять уже заполненные поля, user может только пустые
-		if role == middleware.RoleUser {
-			// Получаем текущие данные пользователя
-			user, errGet := userUpdater.GetUserByEmail(ctx, institute, oldEmail)
-			if errGet != nil {
-				msg := "failed to get current user data"
-				log.Error(msg, sl.Err(errGet))
-				render.JSON(w, r, resp.Error(msg))
+		// Получаем текущие данные пользователя
+		user, err := userUpdater.GetUserByEmail(ctx, institute, oldEmail)
+		if err != nil {
+			msg := "failed to get current user data"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
+			return
+		}
+
+		// Редактор отдела работника может менять любые поля; перевести работника
+		// в другой отдел можно, только если он редактор и там
+		fullEdit := middleware.Can(r, log, authorizer, policy.ActionWorkerUpdate, policy.Scope{Institute: institute, Department: user.Department}) &&
+			(req.Department == user.Department ||
+				middleware.Can(r, log, authorizer, policy.ActionWorkerUpdate, policy.Scope{Institute: institute, Department: req.Department}))
+
+		// Остальные пользователи могут только заполнить пустые поля
+		if !fullEdit {
+			if !middleware.Authorize(w, r, log, authorizer, policy.ActionWorkerFill, policy.Scope{Institute: institute, Department: user.Department}) {
 				return
 			}
 
@@ -179,8 +200,8 @@ func Update(ctx context.Context, log *slog.Logger, userUpdater UserUpdater) http
 
 			if len(forbiddenFields) > 0 {
 				msg := "user can only update empty fields: "
-				log.Warn(msg)
-				render.JSON(w, r, resp.Error(msg))
+				log.WarnContext(ctx, msg)
+				resp.Forbidden(w, r, msg)
 				return
 			}
 		}
@@ -205,19 +226,19 @@ func Update(ctx context.Context, log *slog.Logger, userUpdater UserUpdater) http
 
 		if errors.Is(err, storage.ErrUserNotFound) {
 			msg := "user not found"
-			log.Warn(msg, slog.String("email", oldEmail))
-			render.JSON(w, r, resp.Error(msg))
+			log.WarnContext(ctx, msg, slog.String("email", oldEmail))
+			resp.NotFound(w, r, msg)
 			return
 		}
 
 		if err != nil {
 			msg := "failed to update user"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("user successfully updated",
+		log.InfoContext(ctx, "user successfully updated",
 			slog.String("old_email", oldEmail),
 			slog.String("new_email", req.Email))
 
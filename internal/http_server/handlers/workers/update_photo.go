@@ -5,9 +5,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"strings"
+	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 
 	middleware "telephone-book/internal/http_server/middleware"
@@ -17,73 +17,60 @@ import (
 	"github.com/go-chi/render"
 )
 
-const maxPhotoSizeUpdate = 5 * 1024 * 1024 // 5 MB
-
-var allowedImageTypesUpdate = map[string]bool{
-	"image/jpeg": true,
-	"image/jpg":  true,
-	"image/png":  true,
-	"image/gif":  true,
-	"image/webp": true,
-}
-
 type UpdatePhotoResponse struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+	// PendingID номер фотографии в очереди модерации, если она ждёт одобрения
+	PendingID int `json:"pending_id,omitempty"`
 }
 
 type PhotoUpdater interface {
-	UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte) error
+	PendingPhotoSubmitter
+	UserGetter
+	UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, thumbnails map[int][]byte) error
 }
 
-// UpdatePhoto обновляет фотографию работника
+// UpdatePhoto обновляет фотографию работника.
+// В институтах с модерацией фото от не-администраторов попадает в очередь на одобрение.
 // @Summary Обновить фотографию работника
 // @Tags workers
 // @Accept multipart/form-data
 // @Produce json
 // @Param email path string true "Email работника"
 // @Param institute query string true "Институт"
+// @Param crop_x formData int false "Левый край области кадрирования, px"
+// @Param crop_y formData int false "Верхний край области кадрирования, px"
+// @Param crop_width formData int false "Ширина области кадрирования, px"
+// @Param crop_height formData int false "Высота области кадрирования, px"
 // @Param photo formData file true "Новая фотография"
 // @Success 200 {object} UpdatePhotoResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Failure 403 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem
 // @Router /workers/{email}/photo [put]
-func UpdatePhoto(ctx context.Context, log *slog.Logger, photoUpdater PhotoUpdater) http.HandlerFunc {
+func UpdatePhoto(log *slog.Logger, photoUpdater PhotoUpdater, authorizer middleware.Authorizer, moderation PhotoModeration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.update_photo.UpdatePhoto"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
 
-		// Проверяем роль пользователя - только админы могут обновлять фото
 		role := middleware.GetRole(r.Context(), log)
-		if role != middleware.RoleAdmin {
-			msg := "forbidden: only administrators can update worker photos"
-			log.Warn(msg)
-			render.JSON(w, r, resp.Error(msg))
-			return
-		}
-
-		// Получаем email из URL path, обходя проблему chi с точками в email
-		path := r.URL.Path
-		parts := strings.Split(path, "/")
-		if len(parts) < 4 { // /workers/{email}/photo
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+		if role == middleware.RoleGuest {
+			msg := "unauthorized: only authenticated users can update worker photos"
+			log.WarnContext(ctx, msg)
+			resp.Unauthorized(w, r, msg)
 			return
 		}
 
-		// Декодируем URL-encoded email
-		encodedEmail := parts[2] // /workers/{email}/photo
-		email, err := url.QueryUnescape(encodedEmail)
+		email, err := emailParam(r)
 		if err != nil {
-			msg := "invalid email format"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -91,23 +78,49 @@ func UpdatePhoto(ctx context.Context, log *slog.Logger, photoUpdater PhotoUpdate
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
+			return
+		}
+
+		scope, ok := workerScope(w, r, log, photoUpdater, institute, email)
+		if !ok {
+			return
+		}
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoSubmit, scope) {
+			return
+		}
+
+		// Заменять фото сразу могут редакторы отдела работника; остальные — через модерацию, если она включена
+		canWrite := middleware.Can(r, log, authorizer, policy.ActionPhotoWrite, scope)
+		if !canWrite && !moderation.PhotoModerationEnabled(institute) {
+			msg := "forbidden: not enough permissions to update worker photos"
+			log.WarnContext(ctx, msg)
+			resp.Forbidden(w, r, msg)
 			return
 		}
 
-		log.Info("processing photo update request",
+		log.InfoContext(ctx, "processing photo update request",
 			slog.String("email", email),
 			slog.String("institute", institute))
 
 		// Ограничиваем размер запроса
-		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSizeUpdate+1024*1024)
+		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+1024*1024)
 
 		// Парсим multipart form
-		if err := r.ParseMultipartForm(maxPhotoSizeUpdate); err != nil {
+		if err := r.ParseMultipartForm(maxPhotoSize); err != nil {
 			msg := "failed to parse multipart form"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		// Необязательная область кадрирования для миниатюр
+		crop, err := parseCropForm(r)
+		if err != nil {
+			msg := err.Error()
+			log.WarnContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -115,56 +128,86 @@ func UpdatePhoto(ctx context.Context, log *slog.Logger, photoUpdater PhotoUpdate
 		file, header, err := r.FormFile("photo")
 		if err != nil {
 			msg := "photo file is required"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
 			return
 		}
 		defer file.Close()
 
 		// Проверяем размер файла
-		if header.Size > maxPhotoSizeUpdate {
-			msg := "photo file is too large (max 5MB)"
-			log.Warn(msg, slog.Int64("size", header.Size))
-			render.JSON(w, r, resp.Error(msg))
+		if header.Size > maxPhotoSize {
+			msg := errPhotoTooLarge.Error()
+			log.WarnContext(ctx, msg, slog.Int64("size", header.Size))
+			resp.PayloadTooLarge(w, r, msg)
 			return
 		}
 
-		// Проверяем тип файла
-		contentType := header.Header.Get("Content-Type")
-		if !allowedImageTypesUpdate[contentType] {
-			msg := "unsupported image type"
-			log.Warn(msg, slog.String("content_type", contentType))
-			render.JSON(w, r, resp.Error(msg))
+		// Читаем содержимое файла
+		photo, err := io.ReadAll(io.LimitReader(file, maxPhotoSize+1))
+		if err != nil {
+			msg := "failed to read photo file"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
-		// Читаем содержимое файла
-		photo, err := io.ReadAll(file)
+		// Проверяем содержимое и перекодируем в JPEG без EXIF, заодно строим миниатюры
+		contentType := header.Header.Get("Content-Type")
+		processed, err := processPhoto(photo, contentType, crop)
 		if err != nil {
-			msg := "failed to read photo file"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.WarnContext(ctx, msg, slog.String("content_type", contentType), slog.Int("size", len(photo)))
+			photoProblem(w, r, err)
+			return
+		}
+
+		// В институтах с модерацией фото от не-администраторов ждёт одобрения,
+		// а до тех пор показывается прежнее
+		if !canWrite {
+			pendingID, err := photoUpdater.SubmitPendingPhoto(ctx, institute, email, processed.Original, crop, submitterID(r))
+			if err != nil {
+				if err == storage.ErrUserNotFound {
+					msg := "user not found"
+					log.WarnContext(ctx, msg, slog.String("email", email))
+					resp.NotFound(w, r, msg)
+					return
+				}
+				msg := "failed to submit photo for moderation"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+
+			log.InfoContext(ctx, "photo submitted for moderation",
+				slog.String("email", email),
+				slog.String("institute", institute),
+				slog.Int("pending_id", pendingID))
+
+			render.JSON(w, r, UpdatePhotoResponse{
+				Status:    resp.OK().Status,
+				PendingID: pendingID,
+			})
 			return
 		}
 
 		// Обновляем фотографию в базе данных
-		if err = photoUpdater.UpdateUserPhoto(ctx, institute, email, photo); err != nil {
+		if err = photoUpdater.UpdateUserPhoto(ctx, institute, email, processed.Original, crop, processed.Thumbnails); err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Warn(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.WarnContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 			msg := "failed to update user photo"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("photo updated successfully",
+		log.InfoContext(ctx, "photo updated successfully",
 			slog.String("email", email),
 			slog.String("institute", institute),
-			slog.Int("photo_size", len(photo)))
+			slog.Int("photo_size", len(processed.Original)))
 
 		render.JSON(w, r, UpdatePhotoResponse{
 			Status: resp.OK().Status,
@@ -5,9 +5,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"net/url"
-	"strings"
+	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
 	"telephone-book/internal/storage"
 
 	middleware "telephone-book/internal/http_server/middleware"
@@ -17,44 +17,44 @@ import (
 	"github.com/go-chi/render"
 )
 
-const maxPhotoSizeUpload = 5 * 1024 * 1024 // 5 MB
-
-var allowedImageTypesUpload = map[string]bool{
-	"image/jpeg": true,
-	"image/jpg":  true,
-	"image/png":  true,
-	"image/gif":  true,
-	"image/webp": true,
-}
-
 type UploadPhotoResponse struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+	// PendingID номер фотографии в очереди модерации, если она ждёт одобрения
+	PendingID int `json:"pending_id,omitempty"`
 }
 
 type PhotoUploader interface {
-	UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte) error
+	PendingPhotoSubmitter
+	UserGetter
+	UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, thumbnails map[int][]byte) error
 	GetUserPhoto(ctx context.Context, institute string, email string) ([]byte, error)
 }
 
-// UploadPhoto загружает фотографию работника (только если у него нет фото)
+// UploadPhoto загружает фотографию работника (только если у него нет фото).
+// В институтах с модерацией фото от не-администраторов попадает в очередь на одобрение.
 // @Summary Загрузить фотографию работника
 // @Tags workers
 // @Accept multipart/form-data
 // @Produce json
 // @Param email path string true "Email работника"
 // @Param institute query string true "Институт"
+// @Param crop_x formData int false "Левый край области кадрирования, px"
+// @Param crop_y formData int false "Верхний край области кадрирования, px"
+// @Param crop_width formData int false "Ширина области кадрирования, px"
+// @Param crop_height formData int false "Высота области кадрирования, px"
 // @Param photo formData file true "Фотография"
 // @Success 200 {object} UploadPhotoResponse
-// @Failure 400 {object} response.Response
-// @Failure 401 {object} response.Response
-// @Failure 409 {object} response.Response
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Router /workers/{email}/photo [post]
-func UploadPhoto(ctx context.Context, log *slog.Logger, photoUploader PhotoUploader) http.HandlerFunc {
+func UploadPhoto(log *slog.Logger, photoUploader PhotoUploader, authorizer middleware.Authorizer, moderation PhotoModeration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.workers.upload_photo.UploadPhoto"
+		ctx := r.Context()
 
-		log = log.With(
+		log := log.With(
 			slog.String("operation", op),
 			slog.String("request_id", chimw.GetReqID(r.Context())),
 		)
@@ -63,28 +63,16 @@ func UploadPhoto(ctx context.Context, log *slog.Logger, photoUploader PhotoUploa
 		role := middleware.GetRole(r.Context(), log)
 		if role == middleware.RoleGuest {
 			msg := "unauthorized: only authenticated users can upload worker photos"
-			log.Warn(msg)
-			render.JSON(w, r, resp.Error(msg))
-			return
-		}
-
-		// Получаем email из URL path, обходя проблему chi с точками в email
-		path := r.URL.Path
-		parts := strings.Split(path, "/")
-		if len(parts) < 4 { // /workers/{email}/photo
-			msg := "email not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.WarnContext(ctx, msg)
+			resp.Unauthorized(w, r, msg)
 			return
 		}
 
-		// Декодируем URL-encoded email
-		encodedEmail := parts[2] // /workers/{email}/photo
-		email, err := url.QueryUnescape(encodedEmail)
+		email, err := emailParam(r)
 		if err != nil {
-			msg := "invalid email format"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -92,12 +80,20 @@ func UploadPhoto(ctx context.Context, log *slog.Logger, photoUploader PhotoUploa
 		institute := r.URL.Query().Get("institute")
 		if institute == "" {
 			msg := "institute not specified"
-			log.Error(msg)
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
-		log.Info("processing photo upload request",
+		scope, ok := workerScope(w, r, log, photoUploader, institute, email)
+		if !ok {
+			return
+		}
+		if !middleware.Authorize(w, r, log, authorizer, policy.ActionPhotoSubmit, scope) {
+			return
+		}
+
+		log.InfoContext(ctx, "processing photo upload request",
 			slog.String("email", email),
 			slog.String("institute", institute))
 
@@ -105,26 +101,35 @@ func UploadPhoto(ctx context.Context, log *slog.Logger, photoUploader PhotoUploa
 		existingPhoto, err := photoUploader.GetUserPhoto(ctx, institute, email)
 		if err != nil && err != storage.ErrUserNotFound {
 			msg := "failed to check existing photo"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
 		if len(existingPhoto) > 0 {
 			msg := "user already has a photo, use PUT method to update"
-			log.Warn(msg, slog.String("email", email))
-			render.JSON(w, r, resp.Error(msg))
+			log.WarnContext(ctx, msg, slog.String("email", email))
+			resp.Conflict(w, r, msg)
 			return
 		}
 
 		// Ограничиваем размер запроса
-		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSizeUpload+1024*1024)
+		r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize+1024*1024)
 
 		// Парсим multipart form
-		if err := r.ParseMultipartForm(maxPhotoSizeUpload); err != nil {
+		if err := r.ParseMultipartForm(maxPhotoSize); err != nil {
 			msg := "failed to parse multipart form"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
+			return
+		}
+
+		// Необязательная область кадрирования для миниатюр
+		crop, err := parseCropForm(r)
+		if err != nil {
+			msg := err.Error()
+			log.WarnContext(ctx, msg)
+			resp.BadRequest(w, r, msg)
 			return
 		}
 
@@ -132,56 +137,86 @@ func UploadPhoto(ctx context.Context, log *slog.Logger, photoUploader PhotoUploa
 		file, header, err := r.FormFile("photo")
 		if err != nil {
 			msg := "photo file is required"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BadRequest(w, r, msg)
 			return
 		}
 		defer file.Close()
 
 		// Проверяем размер файла
-		if header.Size > maxPhotoSizeUpload {
-			msg := "photo file is too large (max 5MB)"
-			log.Warn(msg, slog.Int64("size", header.Size))
-			render.JSON(w, r, resp.Error(msg))
+		if header.Size > maxPhotoSize {
+			msg := errPhotoTooLarge.Error()
+			log.WarnContext(ctx, msg, slog.Int64("size", header.Size))
+			resp.PayloadTooLarge(w, r, msg)
 			return
 		}
 
-		// Проверяем тип файла
-		contentType := header.Header.Get("Content-Type")
-		if !allowedImageTypesUpload[contentType] {
-			msg := "unsupported image type"
-			log.Warn(msg, slog.String("content_type", contentType))
-			render.JSON(w, r, resp.Error(msg))
+		// Читаем содержимое файла
+		photo, err := io.ReadAll(io.LimitReader(file, maxPhotoSize+1))
+		if err != nil {
+			msg := "failed to read photo file"
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.BodyError(w, r, err, msg)
 			return
 		}
 
-		// Читаем содержимое файла
-		photo, err := io.ReadAll(file)
+		// Проверяем содержимое и перекодируем в JPEG без EXIF, заодно строим миниатюры
+		contentType := header.Header.Get("Content-Type")
+		processed, err := processPhoto(photo, contentType, crop)
 		if err != nil {
-			msg := "failed to read photo file"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			msg := err.Error()
+			log.WarnContext(ctx, msg, slog.String("content_type", contentType), slog.Int("size", len(photo)))
+			photoProblem(w, r, err)
+			return
+		}
+
+		// В институтах с модерацией фото от не-администраторов ждёт одобрения,
+		// а до тех пор показывается прежнее
+		if needsModeration(r, log, authorizer, moderation, scope) {
+			pendingID, err := photoUploader.SubmitPendingPhoto(ctx, institute, email, processed.Original, crop, submitterID(r))
+			if err != nil {
+				if err == storage.ErrUserNotFound {
+					msg := "user not found"
+					log.WarnContext(ctx, msg, slog.String("email", email))
+					resp.NotFound(w, r, msg)
+					return
+				}
+				msg := "failed to submit photo for moderation"
+				log.ErrorContext(ctx, msg, sl.Err(err))
+				resp.FromError(w, r, err, msg)
+				return
+			}
+
+			log.InfoContext(ctx, "photo submitted for moderation",
+				slog.String("email", email),
+				slog.String("institute", institute),
+				slog.Int("pending_id", pendingID))
+
+			render.JSON(w, r, UploadPhotoResponse{
+				Status:    resp.OK().Status,
+				PendingID: pendingID,
+			})
 			return
 		}
 
 		// Загружаем фотографию в базу данных
-		if err = photoUploader.UpdateUserPhoto(ctx, institute, email, photo); err != nil {
+		if err = photoUploader.UpdateUserPhoto(ctx, institute, email, processed.Original, crop, processed.Thumbnails); err != nil {
 			if err == storage.ErrUserNotFound {
 				msg := "user not found"
-				log.Warn(msg, slog.String("email", email))
-				render.JSON(w, r, resp.Error(msg))
+				log.WarnContext(ctx, msg, slog.String("email", email))
+				resp.NotFound(w, r, msg)
 				return
 			}
 			msg := "failed to upload user photo"
-			log.Error(msg, sl.Err(err))
-			render.JSON(w, r, resp.Error(msg))
+			log.ErrorContext(ctx, msg, sl.Err(err))
+			resp.FromError(w, r, err, msg)
 			return
 		}
 
-		log.Info("photo uploaded successfully",
+		log.InfoContext(ctx, "photo uploaded successfully",
 			slog.String("email", email),
 			slog.String("institute", institute),
-			slog.Int("photo_size", len(photo)))
+			slog.Int("photo_size", len(processed.Original)))
 
 		render.JSON(w, r, UploadPhotoResponse{
 			Status: resp.OK().Status,
@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
-	"telephone-book/internal/clients/sso/grpc"
+	"telephone-book/internal/apikey"
+	"telephone-book/internal/domain/models"
 	"telephone-book/internal/lib/logger/sl"
+	resp "telephone-book/internal/lib/response"
+	"telephone-book/internal/lib/token"
 )
 
 type Role int
@@ -21,57 +25,163 @@ type contextKey string
 
 const (
 	userIDKey contextKey = "userID"
+	emailKey  contextKey = "email"
 	roleKey   contextKey = "role"
+	tokenKey  contextKey = "token"
+	claimsKey contextKey = "claims"
+	apiKeyKey contextKey = "apiKey"
 )
 
-func AuthMiddleware(ssoClient *grpc.Client, log *slog.Logger) func(http.Handler) http.Handler {
+// APIKeyHeader заголовок, в котором сервисы передают ключ API вместо токена
+const APIKeyHeader = "X-API-Key"
+
+// TokenParser проверяет токен SSO
+type TokenParser interface {
+	Parse(tokenString string) (token.Claims, error)
+}
+
+// RevocationChecker проверяет, не отозван ли токен
+type RevocationChecker interface {
+	IsRevoked(tokenString string, claims token.Claims) bool
+}
+
+// RoleResolver узнаёт у SSO, админ ли пользователь; fallback — SSO недоступен и роль взята из кеша
+type RoleResolver interface {
+	IsAdmin(ctx context.Context, userID int64, tokenID string) (isAdmin bool, fallback bool, err error)
+}
+
+// APIKeyAuthenticator находит действующий ключ API
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, raw string) (models.APIKey, error)
+}
+
+func AuthMiddleware(roles RoleResolver, tokenParser TokenParser, revocations RevocationChecker, apiKeys APIKeyAuthenticator, log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			log = log.With(
+			log := log.With(
 				slog.String("operation", "middleware.AuthMiddleware"),
 				slog.String("request_id", r.Header.Get("X-Request-ID")),
 			)
 
+			if rawKey := r.Header.Get(APIKeyHeader); rawKey != "" {
+				serveAPIKey(w, r, next, apiKeys, rawKey, log)
+				return
+			}
+
 			token := extractToken(r, log)
 			if token == "" {
-				log.Debug("no token found in request")
+				log.DebugContext(r.Context(), "no token found in request")
 				ctx := context.WithValue(r.Context(), roleKey, RoleGuest)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			// TODO: получить секрет приложения (app secret) для валидации токена
-			appSecret := "test-secret" // временно, нужно получать из конфига/БД
-			userID, err := grpc.ParseUserIDFromToken(token, appSecret)
+			claims, err := tokenParser.Parse(token)
 			if err != nil {
-				log.Debug("failed to parse user ID from token", sl.Err(err))
+				log.DebugContext(r.Context(), "failed to parse token", sl.Err(err))
 				ctx := context.WithValue(r.Context(), roleKey, RoleGuest)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			isAdmin, err := ssoClient.IsAdmin(r.Context(), userID)
+			if revocations.IsRevoked(token, claims) {
+				log.DebugContext(r.Context(), "token is revoked", slog.Int64("user_id", claims.UserID))
+				ctx := context.WithValue(r.Context(), roleKey, RoleGuest)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			isAdmin, fallback, err := roles.IsAdmin(r.Context(), claims.UserID, claims.TokenID)
+			if err != nil {
+				// Роль неизвестна: пускаем как обычного пользователя, но не молча
+				log.ErrorContext(r.Context(), "failed to get role from sso", slog.Int64("user_id", claims.UserID), sl.Err(err))
+			} else if fallback {
+				log.WarnContext(r.Context(), "sso is unavailable, using last known role", slog.Int64("user_id", claims.UserID), slog.Bool("is_admin", isAdmin))
+			}
+
 			role := RoleUser
 			if err == nil && isAdmin {
 				role = RoleAdmin
 			}
-			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, emailKey, claims.Email)
+			ctx = context.WithValue(ctx, tokenKey, token)
+			ctx = context.WithValue(ctx, claimsKey, claims)
 			ctx = context.WithValue(ctx, roleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// serveAPIKey пускает запрос сервиса как обычного пользователя; права на изменения
+// ограничивает политика по институтам ключа, ключ только для чтения изменять ничего не может.
+// Неизвестный или отозванный ключ — 401, недоступное хранилище ключей — 503
+func serveAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKeys APIKeyAuthenticator, rawKey string, log *slog.Logger) {
+	// Присланный ключ — явная попытка войти как сервис: с неверным ключом запрос не
+	// продолжается гостем, иначе сервис с отозванным ключом молча получал бы гостевые ответы
+	key, err := apiKeys.Authenticate(r.Context(), rawKey)
+	if err != nil {
+		if errors.Is(err, apikey.ErrInvalidKey) {
+			msg := "invalid api key"
+			log.WarnContext(r.Context(), msg)
+			resp.Unauthorized(w, r, msg)
+			return
+		}
+		msg := "failed to check api key"
+		log.ErrorContext(r.Context(), msg, sl.Err(err))
+		resp.Unavailable(w, r, msg)
+		return
+	}
+
+	log = log.With(slog.Int64("api_key_id", key.ID))
+
+	if key.ReadOnly() && !safeMethod(r.Method) {
+		msg := "forbidden: api key is read-only"
+		log.WarnContext(r.Context(), msg, slog.String("method", r.Method), slog.String("route", routePattern(r)))
+		resp.Forbidden(w, r, msg)
+		return
+	}
+
+	log.DebugContext(r.Context(), "request authenticated with api key")
+
+	ctx := context.WithValue(r.Context(), apiKeyKey, key)
+	ctx = context.WithValue(ctx, roleKey, RoleUser)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireAuth пропускает только пользователей с токеном и сервисы с ключом API;
+// гость получает 401. Ставится после AuthMiddleware на маршруты, отдающие справочник целиком
+func RequireAuth(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetRole(r.Context(), log) == RoleGuest {
+				msg := "unauthorized: authentication required"
+				log.WarnContext(r.Context(), msg,
+					slog.String("operation", "middleware.RequireAuth"),
+					slog.String("route", routePattern(r)),
+				)
+				resp.Unauthorized(w, r, msg)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 func extractToken(r *http.Request, log *slog.Logger) string {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		log.Debug("no Authorization header found")
+		log.DebugContext(r.Context(), "no Authorization header found")
 		return ""
 	}
 	parts := strings.SplitN(authHeader, " ", 2)
 	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		log.Debug("invalid Authorization header format", slog.String("header", authHeader))
+		log.DebugContext(r.Context(), "invalid Authorization header format")
 		return ""
 	}
 	return parts[1]
@@ -82,10 +192,32 @@ func GetUserID(ctx context.Context) (int64, bool) {
 	return id, ok
 }
 
+// GetToken проверенный токен запроса и его данные
+func GetToken(ctx context.Context) (string, token.Claims, bool) {
+	tokenString, ok := ctx.Value(tokenKey).(string)
+	if !ok {
+		return "", token.Claims{}, false
+	}
+	claims, ok := ctx.Value(claimsKey).(token.Claims)
+	return tokenString, claims, ok
+}
+
+// GetAPIKey ключ API, с которым пришёл запрос
+func GetAPIKey(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey).(models.APIKey)
+	return key, ok
+}
+
+// GetEmail email пользователя из проверенного токена
+func GetEmail(ctx context.Context) (string, bool) {
+	email, ok := ctx.Value(emailKey).(string)
+	return email, ok && email != ""
+}
+
 func GetRole(ctx context.Context, log *slog.Logger) Role {
 	role, ok := ctx.Value(roleKey).(Role)
 	if !ok {
-		log.Debug("role not found in context, returning RoleGuest")
+		log.DebugContext(ctx, "role not found in context, returning RoleGuest")
 		return RoleGuest
 	}
 	return role
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// deadlineGrace запас времени на запись ответа после отмены контекста
+const deadlineGrace = time.Second
+
+// Deadline ограничивает обработку запроса сроком d: по истечении отменяется контекст запроса,
+// а с ним и запросы к БД. Сроки чтения тела и записи ответа соединения выставляются под d,
+// чтобы длинные маршруты (загрузки, экспорт) не обрывал общий http_server.timeout
+func Deadline(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			// Обёртки ResponseWriter без Unwrap не дают менять сроки; тогда действуют серверные
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(time.Now().Add(d))
+			_ = rc.SetWriteDeadline(time.Now().Add(d + deadlineGrace))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Deprecated помечает устаревшие маршруты: дата объявления в Deprecation (RFC 9745),
+// дата отключения в Sunset (RFC 8594) и ссылка на замену в Link
+func Deprecated(deprecatedAt, sunset time.Time, successor string, log *slog.Logger) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetDate)
+			w.Header().Add("Link", link)
+
+			// Видно, кто ещё не перешёл на новые маршруты
+			log.DebugContext(r.Context(), "legacy route called",
+				slog.String("operation", "middleware.Deprecated"),
+				slog.String("method", r.Method),
+				slog.String("route", routePattern(r)),
+			)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// QueryToURLParam переносит параметр ?name= в параметр пути {name}: устаревший маршрут
+// с адресом объекта в запросе обслуживает тот же обработчик, что и маршрут /api/v1
+func QueryToURLParam(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if value := r.URL.Query().Get(name); value != "" {
+					rctx.URLParams.Add(name, value)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"telephone-book/internal/metrics"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// routeUnmatched метка для запросов, не попавших ни в один маршрут (404, 405)
+const routeUnmatched = "unmatched"
+
+// routePattern шаблон маршрута запроса для логов middleware, которые работают до того,
+// как chi дойдёт до обработчика: шаблон ищется по дереву маршрутов заранее.
+// Сырой путь в логи не пишется, в нём бывают email и id
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return routeUnmatched
+	}
+	if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+		return pattern
+	}
+	return routeUnmatched
+}
+
+// Metrics учитывает запросы и время их обработки по шаблону маршрута и статусу ответа.
+// Шаблон известен только после маршрутизации, поэтому читается после next
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := routeUnmatched
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		metrics.ObserveHTTP(r.Method, route, ww.Status(), time.Since(start))
+	})
+}
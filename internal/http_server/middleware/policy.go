@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/policy"
+
+	resp "telephone-book/internal/lib/response"
+)
+
+// Authorizer проверяет права на изменяющие действия
+type Authorizer interface {
+	Allowed(ctx context.Context, subject policy.Subject, action policy.Action, scope policy.Scope) (bool, error)
+}
+
+// GetSubject пользователь запроса для проверки прав
+func GetSubject(ctx context.Context, log *slog.Logger) policy.Subject {
+	userID, _ := GetUserID(ctx)
+	subject := policy.Subject{
+		UserID:     userID,
+		SuperAdmin: GetRole(ctx, log) == RoleAdmin,
+	}
+	if key, ok := GetAPIKey(ctx); ok {
+		subject.APIKey = &key
+	}
+	return subject
+}
+
+// Can проверяет право без ответа клиенту; ошибка проверки считается отказом
+func Can(r *http.Request, log *slog.Logger, authorizer Authorizer, action policy.Action, scope policy.Scope) bool {
+	allowed, err := authorizer.Allowed(r.Context(), GetSubject(r.Context(), log), action, scope)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to check permissions", slog.String("action", string(action)), sl.Err(err))
+		return false
+	}
+	return allowed
+}
+
+// Authorize проверяет право и при отказе сам отвечает клиенту; false — обработку нужно прервать
+func Authorize(w http.ResponseWriter, r *http.Request, log *slog.Logger, authorizer Authorizer, action policy.Action, scope policy.Scope) bool {
+	if GetRole(r.Context(), log) == RoleGuest {
+		msg := "unauthorized: authentication required"
+		log.WarnContext(r.Context(), msg, slog.String("action", string(action)))
+		resp.Unauthorized(w, r, msg)
+		return false
+	}
+
+	allowed, err := authorizer.Allowed(r.Context(), GetSubject(r.Context(), log), action, scope)
+	if err != nil {
+		msg := "failed to check permissions"
+		log.ErrorContext(r.Context(), msg, slog.String("action", string(action)), sl.Err(err))
+		resp.Internal(w, r, msg)
+		return false
+	}
+
+	if !allowed {
+		msg := "forbidden: not enough permissions"
+		log.WarnContext(r.Context(), msg,
+			slog.String("action", string(action)),
+			slog.String("institute", scope.Institute),
+			slog.String("department", scope.Department),
+		)
+		resp.Forbidden(w, r, msg)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "telephone-book/internal/lib/response"
+)
+
+// ClientLimiter решает, можно ли выполнить запрос клиента сейчас
+type ClientLimiter interface {
+	Allow(client string) (bool, time.Duration)
+}
+
+// RateLimit ограничивает частоту запросов клиента: ключа API, пользователя или, для гостей, адреса
+func RateLimit(limiter ClientLimiter, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientKey(r)
+
+			allowed, retryAfter := limiter.Allow(client)
+			if !allowed {
+				log.WarnContext(r.Context(), "rate limit exceeded",
+					slog.String("operation", "middleware.RateLimit"),
+					slog.String("client", client),
+					slog.String("route", routePattern(r)),
+				)
+				TooManyRequests(w, r, retryAfter, "too many requests")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TooManyRequests отвечает 429 с заголовком Retry-After
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, msg string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	resp.WriteProblem(w, r, http.StatusTooManyRequests, resp.CodeTooManyRequests, msg)
+}
+
+// ClientIP адрес клиента. За прокси RemoteAddr заменяет chi RealIP (rate_limit.trust_proxy)
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func clientKey(r *http.Request) string {
+	if key, ok := GetAPIKey(r.Context()); ok {
+		return fmt.Sprintf("key:%d", key.ID)
+	}
+	if userID, ok := GetUserID(r.Context()); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + ClientIP(r)
+}
@@ -0,0 +1,522 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/imaging"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/lib/parser"
+	"telephone-book/internal/lib/vcard"
+	"telephone-book/internal/metrics"
+	"telephone-book/internal/storage"
+	"time"
+)
+
+const (
+	FormatExcel = "xlsx"
+	FormatVCard = "vcard"
+)
+
+// progressStep как часто (в строках) сохранять прогресс в БД
+const progressStep = 50
+
+var (
+	ErrQueueFull         = errors.New("import queue is full")
+	ErrJobFinished       = errors.New("import job already finished")
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	ErrNoUsers           = errors.New("no users to import")
+	ErrInvalidOrgUnits   = errors.New("invalid missing_org_units option")
+)
+
+type JobStorage interface {
+	CreateImportJob(ctx context.Context, job models.ImportJob, payload []byte, queueSize int) (models.ImportJob, error)
+	ClaimImportJob(ctx context.Context, owner string, lease time.Duration) (models.ImportJob, error)
+	GetImportJobPayload(ctx context.Context, key string) ([]byte, error)
+	DeleteImportJobPayload(ctx context.Context, key string) error
+	UpdateImportJob(ctx context.Context, job models.ImportJob) error
+	GetImportJob(ctx context.Context, id int64) (models.ImportJob, error)
+	RenewImportJobLeases(ctx context.Context, owner string, lease time.Duration) ([]int64, error)
+	RequestImportJobCancel(ctx context.Context, id int64) (bool, error)
+	FailExpiredImportJobs(ctx context.Context, reason string) (int64, error)
+}
+
+type UserCreater interface {
+	CreateUser(
+		ctx context.Context,
+		institute string,
+		surname string,
+		name string,
+		middlename string,
+		email string,
+		personnelNumber string,
+		phoneNumber string,
+		cabinet string,
+		position string,
+		department string,
+		section string,
+		birthDate time.Time,
+		description string,
+		photo []byte,
+	) (int, error)
+}
+
+type task struct {
+	ctx context.Context
+	job models.ImportJob
+}
+
+// Manager выполняет импорт работников в фоне и хранит состояние задач в БД.
+// Очередь — задачи queued в БД, их файлы лежат в BlobStore; воркеры любого экземпляра
+// забирают задачи из неё. Забранная задача арендуется экземпляром: пока он жив, аренда
+// продлевается, а задачи с истёкшей арендой любой экземпляр завершает как оборванные
+type Manager struct {
+	log          *slog.Logger
+	jobs         JobStorage
+	userCreater  UserCreater
+	orgUnits     OrgUnitStorage
+	workers      int
+	queueSize    int
+	pollInterval time.Duration
+	wake         chan struct{}
+	running      sync.WaitGroup
+	instance     string
+	lease        time.Duration
+
+	mu      sync.Mutex
+	cancels map[int64]context.CancelFunc
+}
+
+// New создаёт менеджер; lease — срок аренды задачи, за который экземпляр должен её продлить,
+// pollInterval — как часто простаивающие воркеры проверяют очередь
+func New(log *slog.Logger, jobs JobStorage, userCreater UserCreater, orgUnits OrgUnitStorage, workers int, queueSize int, lease time.Duration, pollInterval time.Duration) *Manager {
+	return &Manager{
+		log:          log,
+		jobs:         jobs,
+		userCreater:  userCreater,
+		orgUnits:     orgUnits,
+		workers:      max(workers, 1),
+		queueSize:    max(queueSize, 1),
+		pollInterval: max(pollInterval, time.Second),
+		wake:         make(chan struct{}, 1),
+		instance:     instanceID(),
+		lease:        lease,
+		cancels:      make(map[int64]context.CancelFunc),
+	}
+}
+
+// Start завершает задачи с истёкшей арендой, запускает воркеры и продление аренды
+func (m *Manager) Start(ctx context.Context) error {
+	const op = "importer.Start"
+
+	if err := m.failExpired(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := 0; i < m.workers; i++ {
+		m.running.Add(1)
+		go m.worker(ctx)
+	}
+
+	go m.heartbeat(ctx)
+
+	return nil
+}
+
+// heartbeat продлевает аренду своих задач, отменяет те, отмену которых запросили другие
+// экземпляры, и завершает задачи упавших экземпляров. Останавливается вместе с ctx:
+// срок аренды больше времени на остановку сервиса, поэтому доделываемые задачи её не теряют
+func (m *Manager) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(max(m.lease/3, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cancelled, err := m.jobs.RenewImportJobLeases(ctx, m.instance, m.lease)
+		if err != nil {
+			m.log.Error("failed to renew import job leases", sl.Err(err))
+		}
+		for _, id := range cancelled {
+			m.log.Info("import job cancel requested", slog.Int64("job_id", id))
+			m.forget(id)
+		}
+
+		if err := m.failExpired(ctx); err != nil {
+			m.log.Error("failed to fail expired import jobs", sl.Err(err))
+		}
+	}
+}
+
+func (m *Manager) failExpired(ctx context.Context) error {
+	failed, err := m.jobs.FailExpiredImportJobs(ctx, "interrupted: service instance stopped")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		m.log.Warn("import jobs with expired lease marked as finished", slog.Int64("count", failed))
+	}
+	return nil
+}
+
+// Wait ждёт, пока воркеры доделают текущие задачи и выйдут после отмены контекста Start.
+// Задачи, не успевшие завершиться до ctx, помечаются оборванными по истечении аренды
+func (m *Manager) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Submit сохраняет файл и ставит задачу в очередь.
+// missingOrgUnits задаёт обработку строк с несуществующими отделами и секциями, пустое значение — OrgUnitsKeep.
+func (m *Manager) Submit(ctx context.Context, institute string, format string, missingOrgUnits string, data []byte, createdBy int64) (models.ImportJob, error) {
+	const op = "importer.Submit"
+
+	if format != FormatExcel && format != FormatVCard {
+		return models.ImportJob{}, ErrUnsupportedFormat
+	}
+
+	if missingOrgUnits == "" {
+		missingOrgUnits = OrgUnitsKeep
+	}
+	if !ValidOrgUnitsMode(missingOrgUnits) {
+		return models.ImportJob{}, ErrInvalidOrgUnits
+	}
+
+	job, err := m.jobs.CreateImportJob(ctx, models.ImportJob{
+		Institute:       institute,
+		Format:          format,
+		Status:          models.ImportJobQueued,
+		MissingOrgUnits: missingOrgUnits,
+		CreatedBy:       createdBy,
+	}, data, m.queueSize)
+	if errors.Is(err, storage.ErrImportQueueFull) {
+		return job, ErrQueueFull
+	}
+	if err != nil {
+		return job, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Свободный воркер этого экземпляра возьмёт задачу сразу, не дожидаясь опроса очереди
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Cancel отменяет задачу в очереди или в процессе выполнения. Задачу другого экземпляра
+// отменяет её владелец при следующем продлении аренды, а задачу в очереди — воркер, который
+// её заберёт; до этого она остаётся в прежнем статусе
+func (m *Manager) Cancel(ctx context.Context, id int64) error {
+	const op = "importer.Cancel"
+
+	job, err := m.jobs.GetImportJob(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if job.Finished() {
+		return ErrJobFinished
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+
+	if !ok {
+		// Задача в очереди или другого экземпляра; если он упал, задачу завершит проверка аренды
+		requested, err := m.jobs.RequestImportJobCancel(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !requested {
+			return ErrJobFinished
+		}
+		return nil
+	}
+
+	cancel()
+
+	return nil
+}
+
+func (m *Manager) worker(ctx context.Context) {
+	defer m.running.Done()
+
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Задачи берутся, пока очередь не опустеет, затем воркер ждёт новой задачи
+		// этого экземпляра или следующего опроса, который заметит задачи других экземпляров
+		for ctx.Err() == nil && m.claim(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim забирает задачу из очереди и выполняет её. false — очередь пуста или недоступна
+func (m *Manager) claim(ctx context.Context) bool {
+	job, err := m.jobs.ClaimImportJob(ctx, m.instance, m.lease)
+	if errors.Is(err, storage.ErrImportJobNotFound) {
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			m.log.Error("failed to claim import job", sl.Err(err))
+		}
+		return false
+	}
+
+	// Задача живёт дольше контекста воркера: начатый импорт доделывается при остановке
+	jobCtx, cancel := context.WithCancel(context.Background())
+
+	m.mu.Lock()
+	m.cancels[job.ID] = cancel
+	m.mu.Unlock()
+
+	m.run(task{ctx: jobCtx, job: job})
+
+	return true
+}
+
+func (m *Manager) run(t task) {
+	job := t.job
+	defer m.forget(job.ID)
+
+	log := m.log.With(
+		slog.String("operation", "importer.run"),
+		slog.Int64("job_id", job.ID),
+		slog.String("institute", job.Institute),
+	)
+
+	if job.CancelRequested {
+		m.finish(job, models.ImportJobCancelled, "")
+		return
+	}
+
+	data, err := m.jobs.GetImportJobPayload(t.ctx, job.PayloadKey)
+	if err != nil {
+		log.Error("failed to load import file", sl.Err(err))
+		m.finish(job, models.ImportJobFailed, "failed to load import file")
+		return
+	}
+
+	users, firstRow, err := parse(job.Format, data)
+	if err == nil && len(users) == 0 {
+		err = ErrNoUsers
+	}
+	if err != nil {
+		log.Error("failed to parse import file", sl.Err(err))
+		m.finish(job, models.ImportJobFailed, err.Error())
+		return
+	}
+
+	var units *orgUnits
+	if job.MissingOrgUnits != OrgUnitsKeep {
+		units, err = loadOrgUnits(t.ctx, m.orgUnits, job.Institute)
+		if err != nil {
+			log.Error("failed to load org units", sl.Err(err))
+			m.finish(job, models.ImportJobFailed, err.Error())
+			return
+		}
+	}
+
+	job.TotalRows = len(users)
+	started := time.Now()
+
+	for i, user := range users {
+		if t.ctx.Err() != nil {
+			log.Info("import job cancelled", slog.Int("processed", job.ProcessedRows))
+			m.finish(job, models.ImportJobCancelled, "")
+			return
+		}
+
+		var err error
+		if units != nil {
+			err = m.checkOrgUnits(t.ctx, units, &job, user)
+		}
+
+		// Фото из vCard сохраняем без EXIF, миниатюры построятся при первом запросе
+		if err == nil && len(user.Photo) > 0 {
+			user.Photo, err = imaging.Normalize(user.Photo)
+		}
+
+		if err == nil {
+			_, err = m.userCreater.CreateUser(
+				t.ctx,
+				job.Institute,
+				user.Surname,
+				user.Name,
+				user.MiddleName,
+				user.Email,
+				user.PersonnelNumber,
+				user.PhoneNumber,
+				user.Cabinet,
+				user.Position,
+				user.Department,
+				user.Section,
+				user.BirthDate,
+				user.Description,
+				user.Photo,
+			)
+		}
+		if err != nil {
+			if t.ctx.Err() != nil {
+				continue
+			}
+			job.FailedRows++
+			job.Errors = append(job.Errors, models.ImportRowError{
+				Row:   firstRow + i,
+				Email: user.Email,
+				Error: err.Error(),
+			})
+		} else {
+			job.ImportedRows++
+		}
+
+		job.ProcessedRows++
+		job.Progress = job.ProcessedRows * 100 / job.TotalRows
+
+		if job.ProcessedRows%progressStep == 0 && !m.save(log, job) {
+			return
+		}
+	}
+
+	m.finish(job, models.ImportJobCompleted, "")
+
+	log.Info("import job completed",
+		slog.Int("imported", job.ImportedRows),
+		slog.Int("failed", job.FailedRows),
+		slog.Duration("duration", time.Since(started)),
+	)
+}
+
+// checkOrgUnits создаёт или отклоняет отсутствующие отдел и секцию строки.
+// Ошибка означает, что строку импортировать нельзя.
+func (m *Manager) checkOrgUnits(ctx context.Context, units *orgUnits, job *models.ImportJob, user models.User) error {
+	var reason string
+
+	switch job.MissingOrgUnits {
+	case OrgUnitsReject:
+		reason = units.rejectReason(user)
+	case OrgUnitsCreate:
+		created, err := units.ensure(ctx, user)
+		if err != nil {
+			reason = err.Error()
+		}
+		job.CreatedOrgUnits = append(job.CreatedOrgUnits, created...)
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	return errors.New(reason)
+}
+
+// parse возвращает пользователей и номер строки файла, соответствующий первому из них
+func parse(format string, data []byte) ([]models.User, int, error) {
+	switch format {
+	case FormatExcel:
+		users, err := parser.Excel(bytes.NewReader(data))
+		// Первая строка листа — заголовки
+		return users, 2, err
+	case FormatVCard:
+		users, err := vcard.Parse(bytes.NewReader(data))
+		return users, 1, err
+	default:
+		return nil, 0, ErrUnsupportedFormat
+	}
+}
+
+func (m *Manager) finish(job models.ImportJob, status string, reason string) {
+	now := time.Now()
+	job.Status = status
+	job.Error = reason
+	job.FinishedAt = &now
+	if status == models.ImportJobCompleted {
+		job.Progress = 100
+	}
+	metrics.ObserveImport(job.Format, status, now.Sub(job.CreatedAt), job.ImportedRows, job.FailedRows)
+	if !m.save(m.log, job) {
+		// Задачу завершили как оборванную, её файл удалён вместе с ней
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.jobs.DeleteImportJobPayload(ctx, job.PayloadKey); err != nil {
+		m.log.Warn("failed to delete import file", slog.Int64("job_id", job.ID), sl.Err(err))
+	}
+}
+
+// save сохраняет состояние задачи; контекст задачи может быть уже отменён.
+// false — задачу уже завершили как оборванную, и выполнять её дальше нельзя
+func (m *Manager) save(log *slog.Logger, job models.ImportJob) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.jobs.UpdateImportJob(ctx, job)
+	if errors.Is(err, storage.ErrImportJobLost) {
+		log.Warn("import job lease lost, stopping", slog.Int64("job_id", job.ID))
+		return false
+	}
+	if err != nil {
+		log.Error("failed to save import job", slog.Int64("job_id", job.ID), sl.Err(err))
+	}
+	return true
+}
+
+// instanceID имя хоста и случайный суффикс: перезапущенный на том же хосте сервис
+// не должен продлевать аренду задач прошлого запуска
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+func (m *Manager) forget(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+		delete(m.cancels, id)
+	}
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"telephone-book/internal/domain/models"
+)
+
+// Что делать со строками, у которых отдел или секция отсутствует в справочнике
+const (
+	// OrgUnitsKeep импортировать как есть, свободным текстом
+	OrgUnitsKeep = "keep"
+	// OrgUnitsCreate создать недостающие отделы и секции
+	OrgUnitsCreate = "create"
+	// OrgUnitsReject не импортировать такие строки
+	OrgUnitsReject = "reject"
+)
+
+type OrgUnitStorage interface {
+	GetAllDepartments(ctx context.Context, institute string) ([]models.Department, error)
+	GetSections(ctx context.Context, institute string, department string) ([]models.Section, error)
+	CreateDepartment(ctx context.Context, institute string, name string, sections []string) (int, error)
+	CreateSection(ctx context.Context, institute string, department string, name string) (int, error)
+}
+
+// ValidOrgUnitsMode проверяет значение опции missing_org_units
+func ValidOrgUnitsMode(mode string) bool {
+	return mode == OrgUnitsKeep || mode == OrgUnitsCreate || mode == OrgUnitsReject
+}
+
+// orgUnits кэш отделов и секций института на время одной задачи
+type orgUnits struct {
+	storage   OrgUnitStorage
+	institute string
+	// отдел -> множество секций
+	departments map[string]map[string]bool
+}
+
+func loadOrgUnits(ctx context.Context, storage OrgUnitStorage, institute string) (*orgUnits, error) {
+	departments, err := storage.GetAllDepartments(ctx, institute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get departments: %w", err)
+	}
+
+	units := &orgUnits{
+		storage:     storage,
+		institute:   institute,
+		departments: make(map[string]map[string]bool, len(departments)),
+	}
+
+	for _, department := range departments {
+		sections, err := storage.GetSections(ctx, institute, department.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sections of %s: %w", department.Name, err)
+		}
+
+		names := make(map[string]bool, len(sections))
+		for _, section := range sections {
+			names[section.Name] = true
+		}
+		units.departments[department.Name] = names
+	}
+
+	return units, nil
+}
+
+// missing возвращает отсутствующие в справочнике отдел и/или секцию пользователя
+func (u *orgUnits) missing(user models.User) (department bool, section bool) {
+	if user.Department == "" {
+		return false, false
+	}
+
+	sections, ok := u.departments[user.Department]
+	if !ok {
+		return true, user.Section != ""
+	}
+
+	return false, user.Section != "" && !sections[user.Section]
+}
+
+// ensure создаёт недостающие отдел и секцию и возвращает созданные единицы
+func (u *orgUnits) ensure(ctx context.Context, user models.User) ([]models.OrgUnit, error) {
+	missingDepartment, missingSection := u.missing(user)
+
+	var created []models.OrgUnit
+
+	if missingDepartment {
+		var sections []string
+		if user.Section != "" {
+			sections = append(sections, user.Section)
+		}
+
+		if _, err := u.storage.CreateDepartment(ctx, u.institute, user.Department, sections); err != nil {
+			return nil, fmt.Errorf("failed to create department %s: %w", user.Department, err)
+		}
+
+		u.departments[user.Department] = make(map[string]bool)
+		created = append(created, models.OrgUnit{Department: user.Department})
+		if user.Section != "" {
+			u.departments[user.Department][user.Section] = true
+			created = append(created, models.OrgUnit{Department: user.Department, Section: user.Section})
+		}
+
+		return created, nil
+	}
+
+	if missingSection {
+		if _, err := u.storage.CreateSection(ctx, u.institute, user.Department, user.Section); err != nil {
+			return nil, fmt.Errorf("failed to create section %s: %w", user.Section, err)
+		}
+
+		u.departments[user.Department][user.Section] = true
+		created = append(created, models.OrgUnit{Department: user.Department, Section: user.Section})
+	}
+
+	return created, nil
+}
+
+// rejectReason текст ошибки для строки с отсутствующим отделом или секцией
+func (u *orgUnits) rejectReason(user models.User) string {
+	missingDepartment, missingSection := u.missing(user)
+
+	switch {
+	case missingDepartment:
+		return fmt.Sprintf("department %q does not exist", user.Department)
+	case missingSection:
+		return fmt.Sprintf("section %q does not exist in department %q", user.Section, user.Department)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,132 @@
+package avatar
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	ContentTypeSVG = "image/svg+xml"
+	ContentTypePNG = "image/png"
+)
+
+// palette фоны аватаров, достаточно тёмные для белого текста
+var palette = []color.RGBA{
+	{0xE5, 0x39, 0x35, 0xFF},
+	{0xD8, 0x1B, 0x60, 0xFF},
+	{0x8E, 0x24, 0xAA, 0xFF},
+	{0x5E, 0x35, 0xB1, 0xFF},
+	{0x39, 0x49, 0xAB, 0xFF},
+	{0x1E, 0x88, 0xE5, 0xFF},
+	{0x03, 0x9B, 0xE5, 0xFF},
+	{0x00, 0x89, 0x7B, 0xFF},
+	{0x43, 0xA0, 0x47, 0xFF},
+	{0x7C, 0xB3, 0x42, 0xFF},
+	{0xF4, 0x51, 0x1E, 0xFF},
+	{0x6D, 0x4C, 0x41, 0xFF},
+	{0x54, 0x6E, 0x7A, 0xFF},
+}
+
+// Initials первые буквы фамилии и имени, например «ИИ» для Иванова Ивана
+func Initials(surname string, name string) string {
+	var b strings.Builder
+	for _, part := range []string{surname, name} {
+		r, _ := utf8.DecodeRuneInString(strings.TrimSpace(part))
+		if r != utf8.RuneError && unicode.IsLetter(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	if b.Len() == 0 {
+		return "?"
+	}
+	return b.String()
+}
+
+// Color фон аватара; один и тот же email всегда получает один цвет
+func Color(email string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// SVG квадратный аватар с инициалами; масштабируется браузером
+func SVG(initials string, background color.RGBA) []byte {
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256" viewBox="0 0 256 256">`+
+			`<rect width="256" height="256" fill="#%02x%02x%02x"/>`+
+			`<text x="50%%" y="50%%" dy=".35em" text-anchor="middle" fill="#ffffff" `+
+			`font-family="Arial, Helvetica, sans-serif" font-size="104" font-weight="bold">%s</text>`+
+			`</svg>`,
+		background.R, background.G, background.B, html.EscapeString(initials),
+	))
+}
+
+var (
+	fontOnce sync.Once
+	fontErr  error
+	boldFont *opentype.Font
+)
+
+// PNG растровый аватар size×size для клиентов без поддержки SVG
+func PNG(initials string, background color.RGBA, size int) ([]byte, error) {
+	const op = "lib.avatar.PNG"
+
+	fontOnce.Do(func() {
+		boldFont, fontErr = opentype.Parse(gobold.TTF)
+	})
+	if fontErr != nil {
+		return nil, fmt.Errorf("%s: failed to parse font: %w", op, fontErr)
+	}
+
+	face, err := opentype.NewFace(boldFont, &opentype.FaceOptions{
+		Size:    float64(size) * 0.4,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer face.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.White),
+		Face: face,
+	}
+
+	// Центрируем по ширине текста и по высоте заглавных букв
+	bounds, _ := drawer.BoundString(initials)
+	textWidth := bounds.Max.X - bounds.Min.X
+	textHeight := bounds.Max.Y - bounds.Min.Y
+	drawer.Dot = fixed.Point26_6{
+		X: (fixed.I(size)-textWidth)/2 - bounds.Min.X,
+		Y: (fixed.I(size)-textHeight)/2 - bounds.Min.Y,
+	}
+	drawer.DrawString(initials)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
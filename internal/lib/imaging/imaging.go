@@ -0,0 +1,294 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // декодер загружаемых фотографий
+	"image/jpeg"
+	_ "image/png" // декодер загружаемых фотографий
+	"mime"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // декодер загружаемых фотографий
+)
+
+// ContentType формат, в котором хранятся фотографии и миниатюры
+const ContentType = "image/jpeg"
+
+const (
+	originalQuality  = 90
+	thumbnailQuality = 85
+)
+
+const (
+	// MaxFileSize максимальный размер загружаемого файла
+	MaxFileSize = 5 * 1024 * 1024 // 5 MB
+	// MaxDimension максимальная ширина и высота в пикселях
+	MaxDimension = 8000
+	// MaxPixels ограничивает память на распакованное изображение: ~4 байта на пиксель,
+	// то есть до ~96 MB, и столько же на копию при повороте по EXIF. 24 Мп — 6000×4000,
+	// кадр обычной фотокамеры
+	MaxPixels = 24_000_000
+	// MinCropSide минимальная сторона области кадрирования
+	MinCropSide = 16
+)
+
+// AllowedTypes типы изображений, которые принимаются при загрузке
+var AllowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ThumbnailSizes размеры квадратных миниатюр в пикселях, по возрастанию
+var ThumbnailSizes = []int{64, 256, 512}
+
+var (
+	ErrInvalidImage  = errors.New("invalid image")
+	ErrImageTooLarge = errors.New("image dimensions are too large")
+	ErrInvalidCrop   = errors.New("crop area is outside the image or too small")
+)
+
+// Photo фотография, готовая к сохранению
+type Photo struct {
+	// Original перекодированный в JPEG оригинал без метаданных
+	Original []byte
+	// Thumbnails миниатюры по размеру стороны
+	Thumbnails map[int][]byte
+}
+
+// Process перекодирует загруженную фотографию в JPEG и строит все миниатюры.
+// Метаданные (EXIF с GPS, моделью камеры и т.п.) при перекодировании отбрасываются,
+// ориентация из EXIF применяется к пикселям. Оригинал не кадрируется:
+// crop (в координатах уже повёрнутого изображения) влияет только на миниатюры.
+func Process(data []byte, crop *image.Rectangle) (Photo, error) {
+	const op = "lib.imaging.Process"
+
+	img, err := Decode(data)
+	if err != nil {
+		return Photo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateCrop(img, crop); err != nil {
+		return Photo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	original, err := encode(img, originalQuality)
+	if err != nil {
+		return Photo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	thumbnails, err := buildThumbnails(img, crop)
+	if err != nil {
+		return Photo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Photo{Original: original, Thumbnails: thumbnails}, nil
+}
+
+// Recrop строит все миниатюры сохранённой фотографии заново по новой области кадрирования
+func Recrop(data []byte, crop *image.Rectangle) (map[int][]byte, error) {
+	const op = "lib.imaging.Recrop"
+
+	img, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := validateCrop(img, crop); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	thumbnails, err := buildThumbnails(img, crop)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return thumbnails, nil
+}
+
+func buildThumbnails(img image.Image, crop *image.Rectangle) (map[int][]byte, error) {
+	thumbnails := make(map[int][]byte, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		thumbnail, err := encode(Thumbnail(img, size, crop), thumbnailQuality)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails[size] = thumbnail
+	}
+	return thumbnails, nil
+}
+
+func validateCrop(img image.Image, crop *image.Rectangle) error {
+	if crop == nil {
+		return nil
+	}
+
+	bounds := img.Bounds()
+	rect := crop.Add(bounds.Min)
+	if rect.Dx() < MinCropSide || rect.Dy() < MinCropSide || !rect.In(bounds) {
+		return ErrInvalidCrop
+	}
+
+	return nil
+}
+
+// Normalize перекодирует фотографию в JPEG без метаданных, не строя миниатюр
+func Normalize(data []byte) ([]byte, error) {
+	const op = "lib.imaging.Normalize"
+
+	img, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	original, err := encode(img, originalQuality)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return original, nil
+}
+
+// Resize строит одну миниатюру из уже сохранённой фотографии
+func Resize(data []byte, size int, crop *image.Rectangle) ([]byte, error) {
+	const op = "lib.imaging.Resize"
+
+	img, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Сохранённая область могла устареть, тогда кадрируем по умолчанию
+	if validateCrop(img, crop) != nil {
+		crop = nil
+	}
+
+	thumbnail, err := encode(Thumbnail(img, size, crop), thumbnailQuality)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return thumbnail, nil
+}
+
+// ThumbnailSize подбирает ближайшую готовую миниатюру не меньше запрошенного размера
+func ThumbnailSize(requested int) int {
+	for _, size := range ThumbnailSizes {
+		if size >= requested {
+			return size
+		}
+	}
+	return ThumbnailSizes[len(ThumbnailSizes)-1]
+}
+
+// DetectType определяет тип изображения по содержимому; пустая строка — тип не поддерживается
+func DetectType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if !AllowedTypes[contentType] {
+		return ""
+	}
+	return contentType
+}
+
+// NormalizeType приводит заявленный клиентом Content-Type к виду, который возвращает DetectType
+func NormalizeType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if mediaType == "image/jpg" || mediaType == "image/pjpeg" {
+		return "image/jpeg"
+	}
+	return mediaType
+}
+
+// Decode декодирует изображение с учётом EXIF ориентации.
+// Размеры проверяются по заголовку до распаковки, чтобы маленький файл
+// не мог заставить выделить гигабайты памяти.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	return orient(img, exifOrientation(data)), nil
+}
+
+// Thumbnail вырезает квадрат из области crop (или из всего изображения) и масштабирует его до size×size.
+// Без crop квадрат выбирает topBiasedSquare; лица на фото не ищутся
+func Thumbnail(img image.Image, size int, crop *image.Rectangle) image.Image {
+	area := img.Bounds()
+	var square image.Rectangle
+	if crop != nil {
+		area = crop.Add(area.Min).Intersect(area)
+		square = centeredSquare(area)
+	} else {
+		square = topBiasedSquare(area)
+	}
+	side := square.Dx()
+
+	// Маленькие фотографии не растягиваем
+	size = min(size, side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, square, draw.Src, nil)
+
+	return dst
+}
+
+// topBiasedSquareOffset у вертикальных фото над квадратом остаётся 1/topBiasedSquareOffset
+// свободной высоты, а не половина, как при центрировании
+const topBiasedSquareOffset = 5
+
+// topBiasedSquare квадрат по центру области; у вертикальной области он сдвинут вверх
+// на фиксированную долю: на типичном портрете голова выше середины кадра. Это
+// постоянный сдвиг, а не поиск лица — для неудачных фото область задаётся кадрированием
+func topBiasedSquare(area image.Rectangle) image.Rectangle {
+	square := centeredSquare(area)
+	if area.Dy() > area.Dx() {
+		y := area.Min.Y + (area.Dy()-square.Dy())/topBiasedSquareOffset
+		square = square.Add(image.Pt(0, y-square.Min.Y))
+	}
+	return square
+}
+
+// centeredSquare наибольший квадрат в центре области
+func centeredSquare(area image.Rectangle) image.Rectangle {
+	side := min(area.Dx(), area.Dy())
+	x := area.Min.X + (area.Dx()-side)/2
+	y := area.Min.Y + (area.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// encode сохраняет изображение в JPEG; прозрачные области заливаются белым
+func encode(img image.Image, quality int) ([]byte, error) {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"telephone-book/internal/domain/models"
 	"time"
 
@@ -13,7 +12,8 @@ import (
 
 const layout = "2006-01-02"
 
-func Excel(file multipart.File) ([]models.User, error) {
+// Excel читает пользователей с первого листа книги, первая строка — заголовки
+func Excel(file io.Reader) ([]models.User, error) {
 	// Читаем в память
 	data, err := io.ReadAll(file)
 	if err != nil {
@@ -45,17 +45,18 @@ func Excel(file multipart.File) ([]models.User, error) {
 		}
 
 		user := models.User{
-			Surname:     getValue(row, 0),
-			Name:        getValue(row, 1),
-			MiddleName:  getValue(row, 2),
-			Email:       getValue(row, 3),
-			PhoneNumber: getValue(row, 4),
-			Cabinet:     getValue(row, 5),
-			Position:    getValue(row, 6),
-			Department:  getValue(row, 7),
-			Section:     getValue(row, 8),
-			BirthDate:   birthDate,
-			Description: getValue(row, 10),
+			Surname:         getValue(row, 0),
+			Name:            getValue(row, 1),
+			MiddleName:      getValue(row, 2),
+			Email:           getValue(row, 3),
+			PhoneNumber:     getValue(row, 4),
+			Cabinet:         getValue(row, 5),
+			Position:        getValue(row, 6),
+			Department:      getValue(row, 7),
+			Section:         getValue(row, 8),
+			BirthDate:       birthDate,
+			Description:     getValue(row, 10),
+			PersonnelNumber: getValue(row, 11),
 		}
 
 		users = append(users, user)
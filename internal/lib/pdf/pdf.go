@@ -0,0 +1,321 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // декодер для проверки фотографий
+	_ "image/jpeg" // декодер для проверки фотографий
+	_ "image/png"  // декодер для проверки фотографий
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"telephone-book/internal/domain/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	fontFamily = "DejaVu"
+
+	noDepartment = "Без отдела"
+	noSection    = ""
+)
+
+// Options настройки вёрстки справочника
+type Options struct {
+	// Title заголовок на первой странице, обычно название института
+	Title string
+	// Photos добавлять фотографии работников
+	Photos bool
+	// Compact одна строка на работника вместо карточки
+	Compact bool
+}
+
+// Generator рендерит телефонный справочник в PDF
+type Generator struct {
+	regular []byte
+	bold    []byte
+}
+
+// New загружает TTF шрифты с поддержкой кириллицы
+func New(fontPath string, boldFontPath string) (*Generator, error) {
+	const op = "lib.pdf.New"
+
+	regular, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read font: %w", op, err)
+	}
+
+	bold, err := os.ReadFile(boldFontPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read bold font: %w", op, err)
+	}
+
+	return &Generator{regular: regular, bold: bold}, nil
+}
+
+// Render пишет справочник: страница срочных служб, затем работники по отделам и секциям.
+// Фотографии берутся из поля Photo и используются только при Options.Photos.
+func (g *Generator) Render(w io.Writer, services []models.Service, users []models.User, opts Options) error {
+	const op = "lib.pdf.Render"
+
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.AddUTF8FontFromBytes(fontFamily, "", g.regular)
+	doc.AddUTF8FontFromBytes(fontFamily, "B", g.bold)
+	doc.SetAutoPageBreak(true, 15)
+	doc.SetFooterFunc(func() {
+		doc.SetY(-12)
+		doc.SetFont(fontFamily, "", 8)
+		doc.CellFormat(0, 5, fmt.Sprintf("%d", doc.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	r := &renderer{doc: doc, opts: opts}
+	r.emergencyPage(services)
+	r.directory(users)
+
+	if err := doc.Output(w); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+type renderer struct {
+	doc  *fpdf.Fpdf
+	opts Options
+}
+
+func (r *renderer) contentWidth() float64 {
+	pageWidth, _ := r.doc.GetPageSize()
+	left, _, right, _ := r.doc.GetMargins()
+	return pageWidth - left - right
+}
+
+func (r *renderer) emergencyPage(services []models.Service) {
+	doc := r.doc
+	doc.AddPage()
+
+	doc.SetFont(fontFamily, "B", 18)
+	doc.CellFormat(0, 12, r.opts.Title, "", 1, "C", false, 0, "")
+	doc.SetFont(fontFamily, "", 11)
+	doc.CellFormat(0, 8, "Телефонный справочник", "", 1, "C", false, 0, "")
+	doc.Ln(6)
+
+	doc.SetFont(fontFamily, "B", 14)
+	doc.CellFormat(0, 10, "Срочные службы", "", 1, "L", false, 0, "")
+
+	width := r.contentWidth()
+	widths := []float64{width * 0.45, width * 0.25, width * 0.30}
+
+	doc.SetFont(fontFamily, "B", 10)
+	doc.SetFillColor(230, 230, 230)
+	for i, title := range []string{"Служба", "Телефон", "Email"} {
+		doc.CellFormat(widths[i], 7, title, "1", 0, "L", true, 0, "")
+	}
+	doc.Ln(-1)
+
+	doc.SetFont(fontFamily, "", 10)
+	for _, service := range services {
+		for i, value := range []string{service.Name, service.PhoneNumber, service.Email} {
+			doc.CellFormat(widths[i], 7, r.fit(value, widths[i]), "1", 0, "L", false, 0, "")
+		}
+		doc.Ln(-1)
+	}
+}
+
+func (r *renderer) directory(users []models.User) {
+	doc := r.doc
+	doc.AddPage()
+
+	sorted := make([]models.User, len(users))
+	copy(sorted, users)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if departmentName(a) != departmentName(b) {
+			return departmentName(a) < departmentName(b)
+		}
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		if a.Surname != b.Surname {
+			return a.Surname < b.Surname
+		}
+		return a.Name < b.Name
+	})
+
+	department, section := "", noSection
+	for i, user := range sorted {
+		if i == 0 || departmentName(user) != department {
+			department, section = departmentName(user), noSection
+			r.heading(department, 14)
+			if user.Section != noSection {
+				section = user.Section
+				r.heading(section, 11)
+			}
+		} else if user.Section != section {
+			section = user.Section
+			r.heading(section, 11)
+		}
+
+		if r.opts.Compact {
+			r.compactRow(user)
+		} else {
+			r.card(user)
+		}
+	}
+}
+
+func (r *renderer) heading(text string, size float64) {
+	r.doc.Ln(2)
+	r.doc.SetFont(fontFamily, "B", size)
+	r.doc.CellFormat(0, size*0.6, text, "B", 1, "L", false, 0, "")
+	r.doc.Ln(1)
+}
+
+// compactRow одна строка: фото, ФИО, телефон, кабинет, email
+func (r *renderer) compactRow(user models.User) {
+	doc := r.doc
+	const height = 7.0
+
+	width := r.contentWidth()
+	left, _, _, _ := doc.GetMargins()
+	photoWidth := 0.0
+	if r.opts.Photos {
+		photoWidth = height
+	}
+	rest := width - photoWidth
+	widths := []float64{rest * 0.40, rest * 0.20, rest * 0.12, rest * 0.28}
+
+	if doc.GetY()+height > pageBottom(doc) {
+		doc.AddPage()
+	}
+
+	y := doc.GetY()
+	if r.opts.Photos {
+		r.photo(user, left, y, photoWidth-1, height-1)
+		doc.SetX(left + photoWidth)
+	}
+
+	doc.SetFont(fontFamily, "", 9)
+	values := []string{fullName(user), user.PhoneNumber, user.Cabinet, user.Email}
+	for i, value := range values {
+		doc.CellFormat(widths[i], height, r.fit(value, widths[i]), "", 0, "L", false, 0, "")
+	}
+	doc.Ln(-1)
+}
+
+// card подробная карточка работника
+func (r *renderer) card(user models.User) {
+	doc := r.doc
+	const (
+		height     = 28.0
+		photoWidth = 21.0
+		lineHeight = 5.0
+	)
+
+	if doc.GetY()+height > pageBottom(doc) {
+		doc.AddPage()
+	}
+
+	left, _, _, _ := doc.GetMargins()
+	y := doc.GetY()
+	textX := left
+	if r.opts.Photos {
+		r.photo(user, left, y+1, photoWidth, height-2)
+		textX += photoWidth + 4
+	}
+	textWidth := r.contentWidth() - (textX - left)
+
+	doc.SetXY(textX, y+1)
+	doc.SetFont(fontFamily, "B", 11)
+	doc.CellFormat(textWidth, 6, r.fit(fullName(user), textWidth), "", 2, "L", false, 0, "")
+
+	doc.SetFont(fontFamily, "", 9)
+	lines := []string{
+		user.Position,
+		labeled("Телефон", user.PhoneNumber),
+		labeled("Кабинет", user.Cabinet),
+		labeled("Email", user.Email),
+	}
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		doc.SetX(textX)
+		doc.CellFormat(textWidth, lineHeight, r.fit(line, textWidth), "", 2, "L", false, 0, "")
+	}
+
+	doc.SetY(y + height)
+}
+
+// photo рисует фотографию, если она в поддерживаемом формате
+func (r *renderer) photo(user models.User, x, y, w, h float64) {
+	if len(user.Photo) == 0 {
+		return
+	}
+
+	var imageType string
+	switch http.DetectContentType(user.Photo) {
+	case "image/jpeg":
+		imageType = "JPG"
+	case "image/png":
+		imageType = "PNG"
+	case "image/gif":
+		imageType = "GIF"
+	default:
+		return
+	}
+
+	// Повреждённое изображение переводит документ в состояние ошибки, поэтому проверяем заранее
+	if _, _, err := image.DecodeConfig(bytes.NewReader(user.Photo)); err != nil {
+		return
+	}
+
+	opts := fpdf.ImageOptions{ImageType: imageType}
+	name := "photo-" + user.Email
+	r.doc.RegisterImageOptionsReader(name, opts, bytes.NewReader(user.Photo))
+	r.doc.ImageOptions(name, x, y, w, h, false, opts, 0, "")
+}
+
+// fit обрезает текст под ширину ячейки
+func (r *renderer) fit(text string, width float64) string {
+	const ellipsis = "…"
+	width -= 2 // внутренние отступы ячейки
+
+	if r.doc.GetStringWidth(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && r.doc.GetStringWidth(string(runes)+ellipsis) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ellipsis
+}
+
+func pageBottom(doc *fpdf.Fpdf) float64 {
+	_, pageHeight := doc.GetPageSize()
+	_, _, _, bottom := doc.GetMargins()
+	return pageHeight - bottom
+}
+
+func departmentName(user models.User) string {
+	if user.Department == "" {
+		return noDepartment
+	}
+	return user.Department
+}
+
+func fullName(user models.User) string {
+	return strings.TrimSpace(strings.Join([]string{user.Surname, user.Name, user.MiddleName}, " "))
+}
+
+func labeled(label string, value string) string {
+	if value == "" {
+		return ""
+	}
+	return label + ": " + value
+}
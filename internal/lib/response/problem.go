@@ -0,0 +1,208 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"telephone-book/internal/storage"
+
+	"github.com/go-playground/validator"
+)
+
+// ContentTypeProblem тип ответа об ошибке по RFC 7807
+const ContentTypeProblem = "application/problem+json"
+
+// Машиночитаемые коды ошибок
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeUnprocessable    = "unprocessable_entity"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "service_unavailable"
+	CodeTimeout          = "deadline_exceeded"
+	CodeUserNotFound     = "user_not_found"
+	CodeUserExists       = "user_already_exists"
+	CodeInstituteUnknown = "institute_not_found"
+	CodeImportNotFound   = "import_job_not_found"
+	CodePhotoNotFound    = "photo_not_found"
+	CodePendingNotFound  = "pending_photo_not_found"
+	CodeGrantNotFound    = "permission_not_found"
+	CodeGrantExists      = "permission_already_exists"
+	CodeAPIKeyNotFound   = "api_key_not_found"
+)
+
+// Problem ответ об ошибке (RFC 7807). Поле error повторяет detail
+// для клиентов, которые читают ответы в прежнем формате
+// @Description Ошибка API в формате application/problem+json
+type Problem struct {
+	// Тип ошибки; about:blank — смысл задают status и code
+	Type string `json:"type"`
+	// Название HTTP статуса. Пример: "Not Found"
+	Title string `json:"title"`
+	// HTTP статус. Пример: 404
+	Status int `json:"status"`
+	// Описание ошибки. Пример: "user not found"
+	Detail string `json:"detail,omitempty"`
+	// Путь запроса. Пример: "/workers/ivanov@example.com"
+	Instance string `json:"instance,omitempty"`
+	// Машиночитаемый код ошибки. Пример: "user_not_found"
+	Code string `json:"code"`
+	// Ошибки отдельных полей запроса
+	Errors []FieldError `json:"errors,omitempty"`
+	// То же, что detail. Пример: "user not found"
+	Error string `json:"error,omitempty"`
+}
+
+// FieldError ошибка проверки поля запроса
+type FieldError struct {
+	// Пример: "Email"
+	Field string `json:"field"`
+	// Нарушенное правило. Пример: "required"
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// storageErrors статусы и коды для ошибок хранилища; одна таблица на все обработчики
+var storageErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{storage.ErrUserNotFound, http.StatusNotFound, CodeUserNotFound},
+	{storage.ErrUserAlreadyExists, http.StatusConflict, CodeUserExists},
+	{storage.ErrSchemaNotExist, http.StatusNotFound, CodeInstituteUnknown},
+	{storage.ErrImportJobNotFound, http.StatusNotFound, CodeImportNotFound},
+	{storage.ErrThumbnailNotFound, http.StatusNotFound, CodePhotoNotFound},
+	{storage.ErrPendingPhotoNotFound, http.StatusNotFound, CodePendingNotFound},
+	{storage.ErrGrantNotFound, http.StatusNotFound, CodeGrantNotFound},
+	{storage.ErrGrantAlreadyExists, http.StatusConflict, CodeGrantExists},
+	{storage.ErrAPIKeyNotFound, http.StatusNotFound, CodeAPIKeyNotFound},
+}
+
+// WriteProblem отвечает ошибкой в формате application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, code string, detail string) {
+	writeProblem(w, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+		Error:    detail,
+	})
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func BadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+func Unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+func Forbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusNotFound, CodeNotFound, detail)
+}
+
+func Conflict(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusConflict, CodeConflict, detail)
+}
+
+func PayloadTooLarge(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, detail)
+}
+
+// Unprocessable запрос разобран, но данные в нём обработать нельзя (например, битое изображение)
+func Unprocessable(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusUnprocessableEntity, CodeUnprocessable, detail)
+}
+
+func Internal(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusInternalServerError, CodeInternal, detail)
+}
+
+func Unavailable(w http.ResponseWriter, r *http.Request, detail string) {
+	WriteProblem(w, r, http.StatusServiceUnavailable, CodeUnavailable, detail)
+}
+
+// BodyError ошибка чтения тела запроса: 413, если превышен лимит http.MaxBytesReader, иначе 400
+func BodyError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		PayloadTooLarge(w, r, fmt.Sprintf("request body is too large (max %d bytes)", tooLarge.Limit))
+		return
+	}
+	BadRequest(w, r, detail)
+}
+
+// FromError отвечает статусом и кодом из таблицы ошибок хранилища;
+// неизвестная ошибка — 500 с detail, чтобы не раскрывать её текст клиенту.
+// Если истёк срок запроса, ошибка хранилища — следствие отмены: 504; ушедшему клиенту не отвечаем
+func FromError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	switch ctxErr := r.Context().Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		WriteProblem(w, r, http.StatusGatewayTimeout, CodeTimeout, "request deadline exceeded")
+		return
+	case errors.Is(ctxErr, context.Canceled):
+		return
+	}
+
+	for _, known := range storageErrors {
+		if errors.Is(err, known.err) {
+			WriteProblem(w, r, known.status, known.code, known.err.Error())
+			return
+		}
+	}
+	Internal(w, r, detail)
+}
+
+// Validation 422 с ошибками отдельных полей
+func Validation(w http.ResponseWriter, r *http.Request, errs validator.ValidationErrors) {
+	fields := make([]FieldError, 0, len(errs))
+	messages := make([]string, 0, len(errs))
+
+	for _, err := range errs {
+		var msg string
+		switch err.ActualTag() {
+		case "required":
+			msg = fmt.Sprintf("field %s is a required field", err.Field())
+		case "email":
+			msg = fmt.Sprintf("field %s is not a vaild Email", err.Field())
+		default:
+			msg = fmt.Sprintf("field %s is not valid", err.Field())
+		}
+		fields = append(fields, FieldError{Field: err.Field(), Rule: err.ActualTag(), Message: msg})
+		messages = append(messages, msg)
+	}
+
+	detail := strings.Join(messages, ",")
+	writeProblem(w, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(http.StatusUnprocessableEntity),
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     CodeValidation,
+		Errors:   fields,
+		Error:    detail,
+	})
+}
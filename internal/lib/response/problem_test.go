@@ -0,0 +1,62 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"telephone-book/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestFromError(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		status int
+		code   string
+	}{
+		{"deadline exceeded", expired, errors.New("canceling statement due to user request"), http.StatusGatewayTimeout, CodeTimeout},
+		{"storage sentinel", context.Background(), fmt.Errorf("op: %w", storage.ErrUserNotFound), http.StatusNotFound, CodeUserNotFound},
+		{"unknown error", context.Background(), errors.New("boom"), http.StatusInternalServerError, ""},
+		{"client went away", cancelled, context.Canceled, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/search", nil).WithContext(tt.ctx)
+			w := httptest.NewRecorder()
+
+			FromError(w, r, tt.err, "failed")
+
+			if tt.status == 0 {
+				if w.Body.Len() != 0 {
+					t.Fatalf("response written for a cancelled request: %d %s", w.Code, w.Body)
+				}
+				return
+			}
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Fatalf("Content-Type = %q", ct)
+			}
+			var p Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if tt.code != "" && p.Code != tt.code {
+				t.Fatalf("code = %q, want %q", p.Code, tt.code)
+			}
+		})
+	}
+}
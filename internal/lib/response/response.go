@@ -1,49 +1,14 @@
 package response
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/go-playground/validator"
-)
-
 // Response базовая структура ответа
 // @Description Базовый ответ API
 type Response struct {
-	// Статус ответа: Ok или Error. Пример: "Ok"
+	// Статус ответа. Пример: "Ok"
 	Status string `json:"status"`
-	// Сообщение об ошибке, если есть. Пример: "invalid request"
-	Error string `json:"error,omitempty"`
 }
 
-const (
-	StatusOk    = "Ok"
-	StatusError = "Error"
-)
+const StatusOk = "Ok"
 
 func OK() Response {
 	return Response{Status: StatusOk}
 }
-
-func Error(msg string) Response {
-	return Response{Status: StatusError, Error: msg}
-}
-
-func ValidationError(errs validator.ValidationErrors) Response {
-	var errMsgs []string
-
-	for _, err := range errs {
-		switch err.ActualTag() {
-		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
-		case "email":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a vaild Email", err.Field()))
-		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
-		}
-	}
-	return Response{
-		Status: StatusError,
-		Error:  strings.Join(errMsgs, ","),
-	}
-}
@@ -0,0 +1,212 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// minJWKSReload не чаще этого перечитываем файл из-за токенов с неизвестным kid
+const minJWKSReload = 5 * time.Second
+
+// JWKS набор открытых ключей из локального файла.
+// Файл перечитывается, когда меняется время его изменения, поэтому ротация
+// сводится к замене файла: старый и новый ключ какое-то время лежат в нём вместе.
+type JWKS struct {
+	path    string
+	refresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWKS загружает ключи; ошибка, если файл не читается при старте
+func NewJWKS(path string, refresh time.Duration) (*JWKS, error) {
+	const op = "lib.token.NewJWKS"
+
+	j := &JWKS{path: path, refresh: refresh}
+	if err := j.reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return j, nil
+}
+
+// Key возвращает ключ по kid, при необходимости перечитав файл
+func (j *JWKS) Key(kid string) (crypto.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	stale := time.Since(j.checkedAt) > j.refresh
+	recent := time.Since(j.checkedAt) < minJWKSReload
+	j.mu.RUnlock()
+
+	// Неизвестный kid может означать, что ключ только что добавили
+	if stale || (!ok && !recent) {
+		// Ошибка перечитывания не критична: продолжаем со старыми ключами
+		_ = j.reload()
+
+		j.mu.RLock()
+		key, ok = j.keys[kid]
+		j.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+func (j *JWKS) reload() error {
+	info, err := os.Stat(j.path)
+	if err != nil {
+		j.touch()
+		return fmt.Errorf("failed to stat jwks: %w", err)
+	}
+
+	j.mu.RLock()
+	unchanged := j.keys != nil && info.ModTime().Equal(j.modTime)
+	j.mu.RUnlock()
+	if unchanged {
+		j.touch()
+		return nil
+	}
+
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		j.touch()
+		return fmt.Errorf("failed to read jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		j.touch()
+		return err
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.modTime = info.ModTime()
+	j.checkedAt = time.Now()
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *JWKS) touch() {
+	j.mu.Lock()
+	j.checkedAt = time.Now()
+	j.mu.Unlock()
+}
+
+// parseJWKS разбирает RSA и EC (P-256) ключи подписи. Остальные и испорченные ключи
+// пропускаются, чтобы один неподходящий ключ в наборе не ломал проверку всех токенов
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	var skipped []error
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k)
+		case "EC":
+			key, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("jwks key %q: %w", k.Kid, err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.Join(append([]error{fmt.Errorf("jwks contains no usable signing keys")}, skipped...)...)
+	}
+
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid rsa key")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y: %w", err)
+	}
+
+	key := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,268 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"telephone-book/internal/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownApp = errors.New("unknown app")
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrNoUserID   = errors.New("uid not found in token")
+	ErrNoAppID    = errors.New("app_id not found in token")
+)
+
+// Claims данные пользователя из токена SSO
+type Claims struct {
+	// TokenID jti токена, а без него — хеш самого токена
+	TokenID   string
+	UserID    int64
+	Email     string
+	AppID     int32
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// appKeys ключи проверки токенов одного приложения SSO
+type appKeys struct {
+	secret    []byte
+	publicKey crypto.PublicKey
+}
+
+// Parser проверяет подпись токенов SSO и извлекает из них данные пользователя.
+// HS256 проверяется секретом приложения из claim app_id, RS256/ES256 — ключом
+// из JWKS по kid, а без kid — открытым ключом приложения.
+type Parser struct {
+	apps     map[int32]appKeys
+	jwks     *JWKS
+	options  []jwt.ParserOption
+	tokenTTL time.Duration
+}
+
+// New собирает ключи из конфига; ошибка, если нет ни одного ключа
+func New(cfg config.Auth) (*Parser, error) {
+	const op = "lib.token.New"
+
+	p := &Parser{
+		apps:     make(map[int32]appKeys),
+		tokenTTL: cfg.TokenTTL,
+		options: []jwt.ParserOption{
+			jwt.WithValidMethods([]string{
+				jwt.SigningMethodHS256.Alg(),
+				jwt.SigningMethodRS256.Alg(),
+				jwt.SigningMethodES256.Alg(),
+			}),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithLeeway(cfg.ClockSkew),
+		},
+	}
+
+	if cfg.Issuer != "" {
+		p.options = append(p.options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		p.options = append(p.options, jwt.WithAudience(cfg.Audience))
+	}
+
+	// Своё приложение известно всегда, даже если его токены проверяются только по JWKS
+	p.apps[cfg.AppID] = appKeys{}
+	if cfg.AppSecret != "" {
+		p.apps[cfg.AppID] = appKeys{secret: []byte(cfg.AppSecret)}
+	}
+
+	for _, app := range cfg.Apps {
+		keys := p.apps[app.ID]
+		if app.Secret != "" {
+			keys.secret = []byte(app.Secret)
+		}
+		if app.PublicKeyPath != "" {
+			key, err := loadPublicKey(app.PublicKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("%s: app %d: %w", op, app.ID, err)
+			}
+			keys.publicKey = key
+		}
+		p.apps[app.ID] = keys
+	}
+
+	if cfg.JWKSPath != "" {
+		jwks, err := NewJWKS(cfg.JWKSPath, cfg.JWKSRefresh)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.jwks = jwks
+	}
+
+	if !p.hasKeys() {
+		return nil, fmt.Errorf("%s: no token verification keys configured", op)
+	}
+
+	return p, nil
+}
+
+func (p *Parser) hasKeys() bool {
+	if p.jwks != nil {
+		return true
+	}
+	for _, keys := range p.apps {
+		if keys.secret != nil || keys.publicKey != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse проверяет подпись, срок действия, издателя, аудиторию и приложение токена
+// и возвращает его данные
+func (p *Parser) Parse(tokenString string) (Claims, error) {
+	token, err := jwt.Parse(tokenString, p.key, p.options...)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, errors.New("invalid token claims")
+	}
+
+	uid, ok := mapClaims["uid"].(float64)
+	if !ok {
+		return Claims{}, ErrNoUserID
+	}
+
+	// Подпись уже проверена ключом приложения, но токен по kid из JWKS
+	// мог быть выдан для приложения, которое мы не обслуживаем
+	app, ok := appID(mapClaims)
+	if !ok {
+		return Claims{}, ErrNoAppID
+	}
+	if _, known := p.apps[app]; !known {
+		return Claims{}, fmt.Errorf("%w: %d", ErrUnknownApp, app)
+	}
+
+	email, _ := mapClaims["email"].(string)
+
+	// exp обязателен и уже проверен парсером
+	expiresAt, _ := mapClaims.GetExpirationTime()
+
+	// Без iat время выдачи оцениваем по сроку жизни токенов SSO
+	issuedAt := expiresAt.Add(-p.tokenTTL)
+	if iat, err := mapClaims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
+	}
+
+	tokenID, _ := mapClaims["jti"].(string)
+	if tokenID == "" {
+		sum := sha256.Sum256([]byte(tokenString))
+		tokenID = hex.EncodeToString(sum[:16])
+	}
+
+	return Claims{
+		TokenID:   tokenID,
+		UserID:    int64(uid),
+		Email:     email,
+		AppID:     app,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+// key выбирает ключ проверки подписи по алгоритму, kid и app_id токена
+func (p *Parser) key(token *jwt.Token) (interface{}, error) {
+	claims, _ := token.Claims.(jwt.MapClaims)
+	id, _ := appID(claims)
+	app, hasApp := p.apps[id]
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if !hasApp || app.secret == nil {
+			return nil, ErrUnknownApp
+		}
+		return app.secret, nil
+
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if kid, ok := token.Header["kid"].(string); ok && p.jwks != nil {
+			key, err := p.jwks.Key(kid)
+			if err != nil {
+				return nil, err
+			}
+			return checkKeyType(token.Method, key)
+		}
+		if !hasApp || app.publicKey == nil {
+			return nil, ErrUnknownKey
+		}
+		return checkKeyType(token.Method, app.publicKey)
+	}
+
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// checkKeyType не даёт проверить ES256 токен RSA ключом и наоборот
+func checkKeyType(method jwt.SigningMethod, key crypto.PublicKey) (crypto.PublicKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key type does not match signing method %s", method.Alg())
+}
+
+// appID приложение из claim app_id
+func appID(claims jwt.MapClaims) (int32, bool) {
+	id, ok := claims["app_id"].(float64)
+	return int32(id), ok
+}
+
+// loadPublicKey читает открытый ключ RSA или ECDSA в PEM (PKIX или сертификат)
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		key = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", key)
+}
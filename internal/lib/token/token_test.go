@@ -0,0 +1,460 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"telephone-book/internal/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testAppID  = 1
+	testSecret = "test-secret"
+	foreignApp = 99
+	keyedAppID = 2
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	return key
+}
+
+// writeJWKS записывает набор открытых ключей и сдвигает время изменения файла,
+// чтобы перезапись в том же тесте была заметна JWKS
+func writeJWKS(t *testing.T, path string, keys map[string]crypto.PublicKey) {
+	t.Helper()
+
+	var set jwkSet
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, jwk{
+				Kid: kid,
+				Kty: "EC",
+				Use: "sig",
+				Crv: "P-256",
+				X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32))),
+				Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32))),
+			})
+		default:
+			t.Fatalf("unsupported key type %T", key)
+		}
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	} else {
+		modTime = time.Now()
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes jwks: %v", err)
+	}
+}
+
+func writePublicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	return path
+}
+
+// sign подписывает токен; пустой kid не попадает в заголовок
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func validClaims(app int32) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"uid":    float64(42),
+		"email":  "ivanov@example.com",
+		"app_id": float64(app),
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Hour).Unix(),
+	}
+}
+
+func testAuthConfig() config.Auth {
+	return config.Auth{
+		AppID:       testAppID,
+		AppSecret:   testSecret,
+		JWKSRefresh: time.Hour,
+		ClockSkew:   30 * time.Second,
+		TokenTTL:    time.Hour,
+	}
+}
+
+// Токен нельзя проверить ключом другого типа: ни подменой alg на HS256 с открытым ключом
+// вместо секрета, ни RS256/ES256 с kid ключа другого семейства
+func TestParseRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ecKey := newECKey(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{
+		"rsa": &rsaKey.PublicKey,
+		"ec":  &ecKey.PublicKey,
+	})
+
+	cfg := testAuthConfig()
+	cfg.JWKSPath = jwksPath
+	cfg.Apps = []config.AuthApp{{ID: keyedAppID, PublicKeyPath: writePublicKeyPEM(t, &rsaKey.PublicKey)}}
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	publicPEM, err := os.ReadFile(cfg.Apps[0].PublicKeyPath)
+	if err != nil {
+		t.Fatalf("read public key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"hs256 signed with app public key", sign(t, jwt.SigningMethodHS256, publicPEM, "", validClaims(keyedAppID))},
+		{"es256 with kid of rsa key", sign(t, jwt.SigningMethodES256, ecKey, "rsa", validClaims(testAppID))},
+		{"rs256 with kid of ec key", sign(t, jwt.SigningMethodRS256, rsaKey, "ec", validClaims(testAppID))},
+		{"es256 without kid for app with rsa key", sign(t, jwt.SigningMethodES256, ecKey, "", validClaims(keyedAppID))},
+		{"none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims(testAppID))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Parse(tt.token); err == nil {
+				t.Fatal("Parse accepted token, want error")
+			}
+		})
+	}
+
+	// Те же ключи со своим алгоритмом принимаются
+	if _, err := p.Parse(sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims(testAppID))); err != nil {
+		t.Fatalf("Parse rs256 with matching kid: %v", err)
+	}
+	if _, err := p.Parse(sign(t, jwt.SigningMethodES256, ecKey, "ec", validClaims(testAppID))); err != nil {
+		t.Fatalf("Parse es256 with matching kid: %v", err)
+	}
+	if _, err := p.Parse(sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims(keyedAppID))); err != nil {
+		t.Fatalf("Parse rs256 with app public key: %v", err)
+	}
+}
+
+func TestCheckKeyType(t *testing.T) {
+	rsaPub := &newRSAKey(t).PublicKey
+	ecPub := &newECKey(t).PublicKey
+
+	tests := []struct {
+		method jwt.SigningMethod
+		key    crypto.PublicKey
+		ok     bool
+	}{
+		{jwt.SigningMethodRS256, rsaPub, true},
+		{jwt.SigningMethodES256, ecPub, true},
+		{jwt.SigningMethodRS256, ecPub, false},
+		{jwt.SigningMethodES256, rsaPub, false},
+		{jwt.SigningMethodHS256, rsaPub, false},
+	}
+
+	for _, tt := range tests {
+		_, err := checkKeyType(tt.method, tt.key)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkKeyType(%s, %T) error = %v, want ok %v", tt.method.Alg(), tt.key, err, tt.ok)
+		}
+	}
+}
+
+func TestParseUnknownKid(t *testing.T) {
+	known := newRSAKey(t)
+	unknown := newRSAKey(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{"known": &known.PublicKey})
+
+	cfg := testAuthConfig()
+	cfg.JWKSPath = jwksPath
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	_, err = p.Parse(sign(t, jwt.SigningMethodRS256, unknown, "unknown", validClaims(testAppID)))
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Parse error = %v, want ErrUnknownKey", err)
+	}
+
+	// Чужой ключ под известным kid не проходит проверку подписи
+	_, err = p.Parse(sign(t, jwt.SigningMethodRS256, unknown, "known", validClaims(testAppID)))
+	if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Fatalf("Parse error = %v, want ErrTokenSignatureInvalid", err)
+	}
+}
+
+// Подпись ключом из JWKS не делает токен чужого приложения нашим
+func TestParseForeignApp(t *testing.T) {
+	key := newRSAKey(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{"sso": &key.PublicKey})
+
+	cfg := testAuthConfig()
+	cfg.JWKSPath = jwksPath
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	_, err = p.Parse(sign(t, jwt.SigningMethodRS256, key, "sso", validClaims(foreignApp)))
+	if !errors.Is(err, ErrUnknownApp) {
+		t.Fatalf("Parse RS256 error = %v, want ErrUnknownApp", err)
+	}
+
+	noApp := validClaims(testAppID)
+	delete(noApp, "app_id")
+	_, err = p.Parse(sign(t, jwt.SigningMethodRS256, key, "sso", noApp))
+	if !errors.Is(err, ErrNoAppID) {
+		t.Fatalf("Parse RS256 without app_id error = %v, want ErrNoAppID", err)
+	}
+
+	// HS256 токен чужого приложения нечем проверить
+	_, err = p.Parse(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims(foreignApp)))
+	if !errors.Is(err, ErrUnknownApp) {
+		t.Fatalf("Parse HS256 error = %v, want ErrUnknownApp", err)
+	}
+
+	claims, err := p.Parse(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims(testAppID)))
+	if err != nil {
+		t.Fatalf("Parse own app: %v", err)
+	}
+	if claims.AppID != testAppID || claims.UserID != 42 {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+// После замены файла JWKS токены нового ключа принимаются, а ключа, убранного из набора, — нет
+func TestParseRotatedJWKS(t *testing.T) {
+	oldKey := newRSAKey(t)
+	newKey := newECKey(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{"old": &oldKey.PublicKey})
+
+	cfg := testAuthConfig()
+	cfg.JWKSPath = jwksPath
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	oldToken := sign(t, jwt.SigningMethodRS256, oldKey, "old", validClaims(testAppID))
+	newToken := sign(t, jwt.SigningMethodES256, newKey, "new", validClaims(testAppID))
+
+	if _, err := p.Parse(oldToken); err != nil {
+		t.Fatalf("Parse old token before rotation: %v", err)
+	}
+
+	// Ротация: сначала оба ключа, затем только новый
+	// Неизвестный kid перечитывает файл, если последняя проверка была раньше minJWKSReload
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{"old": &oldKey.PublicKey, "new": &newKey.PublicKey})
+	ageJWKSCheck(p.jwks, minJWKSReload+time.Second)
+
+	if _, err := p.Parse(newToken); err != nil {
+		t.Fatalf("Parse new token after key was added: %v", err)
+	}
+	if _, err := p.Parse(oldToken); err != nil {
+		t.Fatalf("Parse old token while both keys are published: %v", err)
+	}
+
+	// Известный kid перечитывает файл только по истечении JWKSRefresh
+	writeJWKS(t, jwksPath, map[string]crypto.PublicKey{"new": &newKey.PublicKey})
+	if _, err := p.Parse(oldToken); err != nil {
+		t.Fatalf("Parse old token before refresh: %v", err)
+	}
+	ageJWKSCheck(p.jwks, cfg.JWKSRefresh+time.Second)
+
+	if _, err := p.Parse(oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Parse old token after its key was removed: error = %v, want ErrUnknownKey", err)
+	}
+	if _, err := p.Parse(newToken); err != nil {
+		t.Fatalf("Parse new token after rotation: %v", err)
+	}
+}
+
+// ageJWKSCheck сдвигает последнюю проверку файла на age назад, как если бы прошло это время
+func ageJWKSCheck(j *JWKS, age time.Duration) {
+	j.mu.Lock()
+	j.checkedAt = time.Now().Add(-age)
+	j.mu.Unlock()
+}
+
+// Обязательные и необязательные claims, допуск на расхождение часов, iss и aud
+func TestParseClaims(t *testing.T) {
+	now := time.Now()
+
+	with := func(edit func(jwt.MapClaims)) jwt.MapClaims {
+		claims := validClaims(testAppID)
+		edit(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		issuer   string
+		audience string
+		claims   jwt.MapClaims
+		wantErr  error
+	}{
+		{"valid", "", "", validClaims(testAppID), nil},
+		{"no exp", "", "", with(func(c jwt.MapClaims) { delete(c, "exp") }), jwt.ErrTokenRequiredClaimMissing},
+		// Без app_id не найти и секрет HS256; токен по kid из JWKS — TestParseForeignApp
+		{"no app_id", "", "", with(func(c jwt.MapClaims) { delete(c, "app_id") }), ErrUnknownApp},
+		{"no uid", "", "", with(func(c jwt.MapClaims) { delete(c, "uid") }), ErrNoUserID},
+		{"expired within skew", "", "", with(func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() }), nil},
+		{"expired beyond skew", "", "", with(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() }), jwt.ErrTokenExpired},
+		{"issued in future within skew", "", "", with(func(c jwt.MapClaims) { c["iat"] = now.Add(10 * time.Second).Unix() }), nil},
+		{"issued in future beyond skew", "", "", with(func(c jwt.MapClaims) { c["iat"] = now.Add(time.Minute).Unix() }), jwt.ErrTokenUsedBeforeIssued},
+		{"iss not checked", "", "", with(func(c jwt.MapClaims) { c["iss"] = "other" }), nil},
+		{"iss matches", "sso", "", with(func(c jwt.MapClaims) { c["iss"] = "sso" }), nil},
+		{"iss differs", "sso", "", with(func(c jwt.MapClaims) { c["iss"] = "other" }), jwt.ErrTokenInvalidIssuer},
+		{"iss missing", "sso", "", validClaims(testAppID), jwt.ErrTokenRequiredClaimMissing},
+		{"aud not checked", "", "", with(func(c jwt.MapClaims) { c["aud"] = "other" }), nil},
+		{"aud contains expected", "", "telephone-book", with(func(c jwt.MapClaims) { c["aud"] = []string{"other", "telephone-book"} }), nil},
+		{"aud differs", "", "telephone-book", with(func(c jwt.MapClaims) { c["aud"] = "other" }), jwt.ErrTokenInvalidAudience},
+		{"aud missing", "", "telephone-book", validClaims(testAppID), jwt.ErrTokenRequiredClaimMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testAuthConfig()
+			cfg.Issuer = tt.issuer
+			cfg.Audience = tt.audience
+
+			p, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			_, err = p.Parse(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", tt.claims))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Без iat время выдачи считается по TokenTTL, без jti идентификатором служит хеш токена
+func TestParseOptionalClaims(t *testing.T) {
+	p, err := New(testAuthConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	claims := validClaims(testAppID)
+	delete(claims, "iat")
+	exp := time.Unix(claims["exp"].(int64), 0)
+
+	tokenString := sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims)
+	parsed, err := p.Parse(tokenString)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := exp.Add(-time.Hour); !parsed.IssuedAt.Equal(want) {
+		t.Fatalf("IssuedAt = %v, want %v", parsed.IssuedAt, want)
+	}
+	if parsed.TokenID == "" {
+		t.Fatal("TokenID is empty for token without jti")
+	}
+
+	again, err := p.Parse(tokenString)
+	if err != nil {
+		t.Fatalf("Parse again: %v", err)
+	}
+	if again.TokenID != parsed.TokenID {
+		t.Fatalf("TokenID changed between parses: %q, %q", parsed.TokenID, again.TokenID)
+	}
+
+	claims["jti"] = "session-1"
+	parsed, err = p.Parse(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+	if err != nil {
+		t.Fatalf("Parse with jti: %v", err)
+	}
+	if parsed.TokenID != "session-1" {
+		t.Fatalf("TokenID = %q, want jti", parsed.TokenID)
+	}
+}
@@ -0,0 +1,233 @@
+package vcard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"telephone-book/internal/domain/models"
+	"time"
+)
+
+var ErrNoCards = errors.New("no vcards found")
+
+// property одна строка карточки вида NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse читает vCard 3.0/4.0 (один или несколько vcard подряд) и возвращает пользователей
+func Parse(r io.Reader) ([]models.User, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vcard: %w", err)
+	}
+
+	var users []models.User
+	var current *models.User
+	var formattedName string
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			if strings.EqualFold(prop.value, "VCARD") {
+				current = &models.User{}
+				formattedName = ""
+			}
+			continue
+		case "END":
+			if strings.EqualFold(prop.value, "VCARD") && current != nil {
+				if current.Surname == "" && current.Name == "" {
+					current.Surname, current.Name, current.MiddleName = splitFullName(formattedName)
+				}
+				users = append(users, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch prop.name {
+		case "N":
+			parts := splitEscaped(prop.value, ';')
+			current.Surname = part(parts, 0)
+			current.Name = part(parts, 1)
+			current.MiddleName = part(parts, 2)
+		case "FN":
+			formattedName = unescape(prop.value)
+		case "EMAIL":
+			if current.Email == "" {
+				current.Email = unescape(prop.value)
+			}
+		case "TEL":
+			if current.PhoneNumber == "" {
+				current.PhoneNumber = strings.TrimPrefix(unescape(prop.value), "tel:")
+			}
+		case "TITLE":
+			current.Position = unescape(prop.value)
+		case "ORG":
+			parts := splitEscaped(prop.value, ';')
+			current.Department = part(parts, 0)
+			current.Section = part(parts, 1)
+		case "ADR":
+			if current.Cabinet == "" {
+				current.Cabinet = part(splitEscaped(prop.value, ';'), 1)
+			}
+		case "NOTE":
+			current.Description = unescape(prop.value)
+		case "BDAY":
+			current.BirthDate = parseDate(prop.value)
+		case "PHOTO":
+			photo, err := decodePhoto(prop)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			current.Photo = photo
+		}
+	}
+
+	if len(users) == 0 {
+		return nil, ErrNoCards
+	}
+
+	return users, nil
+}
+
+// unfold склеивает перенесённые строки (RFC 6350, 3.2)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (property, error) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return property{}, fmt.Errorf("invalid vcard line %q", line)
+	}
+
+	head := strings.Split(line[:colon], ";")
+	name := strings.ToUpper(head[0])
+	// Отбрасываем группу: item1.EMAIL -> EMAIL
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	params := make(map[string]string, len(head)-1)
+	for _, p := range head[1:] {
+		key, value, found := strings.Cut(p, "=")
+		if !found {
+			// vCard 2.1: параметр без имени, например PHOTO;JPEG;ENCODING=BASE64
+			params["TYPE"] = strings.ToUpper(key)
+			continue
+		}
+		params[strings.ToUpper(key)] = strings.ToUpper(strings.Trim(value, `"`))
+	}
+
+	return property{name: name, params: params, value: line[colon+1:]}, nil
+}
+
+func decodePhoto(prop property) ([]byte, error) {
+	value := prop.value
+
+	if strings.HasPrefix(value, "data:") {
+		_, data, found := strings.Cut(value, ",")
+		if !found {
+			return nil, errors.New("invalid photo data uri")
+		}
+		value = data
+	} else {
+		encoding := prop.params["ENCODING"]
+		if encoding != "B" && encoding != "BASE64" {
+			// Ссылки на внешние фотографии не загружаем
+			return nil, nil
+		}
+	}
+
+	photo, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode photo: %w", err)
+	}
+
+	return photo, nil
+}
+
+func parseDate(value string) time.Time {
+	for _, layout := range []string{"2006-01-02", "20060102", "2006-01-02T15:04:05Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func splitFullName(fn string) (string, string, string) {
+	parts := strings.Fields(fn)
+	return part(parts, 0), part(parts, 1), strings.Join(parts[min(2, len(parts)):], " ")
+}
+
+// splitEscaped делит структурированное значение по sep с учётом экранирования
+func splitEscaped(value string, sep byte) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			b.WriteByte(value[i])
+			b.WriteByte(value[i+1])
+			i++
+		case value[i] == sep:
+			parts = append(parts, unescape(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return append(parts, unescape(b.String()))
+}
+
+var unescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\,`, ",",
+	`\;`, ";",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+func part(parts []string, index int) string {
+	if len(parts) > index {
+		return strings.TrimSpace(parts[index])
+	}
+	return ""
+}
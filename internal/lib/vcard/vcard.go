@@ -0,0 +1,172 @@
+package vcard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"telephone-book/internal/domain/models"
+)
+
+const (
+	Version3 = "3.0"
+	Version4 = "4.0"
+)
+
+// maxLineLength максимальная длина строки в октетах (RFC 6350, 3.2)
+const maxLineLength = 75
+
+// ContentType MIME-тип vCard файлов
+const ContentType = "text/vcard; charset=utf-8"
+
+// Write записывает пользователей в формате vCard указанной версии.
+// Фотография пользователя (поле Photo) встраивается в карточку в base64.
+func Write(w io.Writer, users []models.User, version string) error {
+	if version != Version3 && version != Version4 {
+		return fmt.Errorf("unsupported vcard version: %s", version)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, user := range users {
+		for _, line := range card(user, version) {
+			if err := writeFolded(bw, line); err != nil {
+				return fmt.Errorf("failed to write vcard: %w", err)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// card формирует строки одной карточки
+func card(user models.User, version string) []string {
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:" + version,
+		"N:" + joinEscaped(";", user.Surname, user.Name, user.MiddleName, "", ""),
+		"FN:" + escape(fullName(user)),
+	}
+
+	if user.Department != "" || user.Section != "" {
+		lines = append(lines, "ORG:"+joinEscaped(";", user.Department, user.Section))
+	}
+	if user.Position != "" {
+		lines = append(lines, "TITLE:"+escape(user.Position))
+	}
+
+	if version == Version3 {
+		if user.Email != "" {
+			lines = append(lines, "EMAIL;TYPE=INTERNET,WORK:"+escape(user.Email))
+		}
+		if user.PhoneNumber != "" {
+			lines = append(lines, "TEL;TYPE=WORK,VOICE:"+escape(user.PhoneNumber))
+		}
+		if user.Cabinet != "" {
+			lines = append(lines, "ADR;TYPE=WORK:"+joinEscaped(";", "", user.Cabinet, "", "", "", "", ""))
+		}
+	} else {
+		if user.Email != "" {
+			lines = append(lines, "EMAIL;TYPE=work:"+escape(user.Email))
+		}
+		if user.PhoneNumber != "" {
+			lines = append(lines, "TEL;TYPE=work,voice:"+escape(user.PhoneNumber))
+		}
+		if user.Cabinet != "" {
+			lines = append(lines, "ADR;TYPE=work:"+joinEscaped(";", "", user.Cabinet, "", "", "", "", ""))
+		}
+	}
+
+	if !user.BirthDate.IsZero() {
+		if version == Version3 {
+			lines = append(lines, "BDAY:"+user.BirthDate.Format("2006-01-02"))
+		} else {
+			lines = append(lines, "BDAY:"+user.BirthDate.Format("20060102"))
+		}
+	}
+	if user.Description != "" {
+		lines = append(lines, "NOTE:"+escape(user.Description))
+	}
+
+	if len(user.Photo) > 0 {
+		mime := http.DetectContentType(user.Photo)
+		data := base64.StdEncoding.EncodeToString(user.Photo)
+		if version == Version3 {
+			lines = append(lines, fmt.Sprintf("PHOTO;ENCODING=b;TYPE=%s:%s", photoType(mime), data))
+		} else {
+			lines = append(lines, fmt.Sprintf("PHOTO:data:%s;base64,%s", mime, data))
+		}
+	}
+
+	return append(lines, "END:VCARD")
+}
+
+func fullName(user models.User) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{user.Surname, user.Name, user.MiddleName} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// photoType возвращает значение параметра TYPE для vCard 3.0
+func photoType(mime string) string {
+	switch mime {
+	case "image/jpeg":
+		return "JPEG"
+	case "image/png":
+		return "PNG"
+	case "image/gif":
+		return "GIF"
+	case "image/webp":
+		return "WEBP"
+	default:
+		return "JPEG"
+	}
+}
+
+// writeFolded пишет строку, перенося её по 75 октетов без разрыва UTF-8 символов
+func writeFolded(w *bufio.Writer, line string) error {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		if _, err := w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+		line = line[cut:]
+		// Продолжение начинается с пробела, который тоже учитывается в длине
+		limit = maxLineLength - 1
+	}
+	_, err := w.WriteString(line + "\r\n")
+	return err
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func joinEscaped(sep string, values ...string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = escape(v)
+	}
+	return strings.Join(escaped, sep)
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	",", `\,`,
+	";", `\;`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
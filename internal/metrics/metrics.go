@@ -0,0 +1,196 @@
+// Package metrics метрики сервиса в формате Prometheus; отдаются на /metrics
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"telephone-book/internal/rolecache"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "telephone_book"
+
+// Registry свой реестр вместо глобального, чтобы на /metrics не попадало лишнее из зависимостей
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP запросы по маршруту и статусу ответа.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Время обработки HTTP запросов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	ssoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "sso",
+		Name:      "call_duration_seconds",
+		Help:      "Время вызовов SSO по gRPC, с учётом повторов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	ssoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "sso",
+		Name:      "call_errors_total",
+		Help:      "Вызовы SSO, завершившиеся ошибкой.",
+	}, []string{"method", "code"})
+
+	importDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "import",
+		Name:      "job_duration_seconds",
+		Help:      "Время задач импорта от постановки в очередь до завершения.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"format", "status"})
+
+	importRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "import",
+		Name:      "rows_total",
+		Help:      "Строки файлов импорта: imported или failed.",
+	}, []string{"format", "result"})
+
+	// Без метки института: он приходит из запроса, и число рядов не ограничено
+	searchQueries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "search",
+		Name:      "queries_total",
+		Help:      "Поисковые запросы.",
+	})
+
+	searchZeroResults = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "search",
+		Name:      "zero_results_total",
+		Help:      "Поисковые запросы без результатов.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		ssoDuration,
+		ssoErrors,
+		importDuration,
+		importRows,
+		searchQueries,
+		searchZeroResults,
+	)
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTP учитывает обработанный HTTP запрос; route — шаблон маршрута chi, а не путь,
+// чтобы email и id не раздували число рядов
+func ObserveHTTP(method string, route string, status int, duration time.Duration) {
+	code := statusLabel(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// SSOInterceptor измеряет вызовы SSO; ставится перед перехватчиком повторов, поэтому
+// время включает все попытки
+func SSOInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		code := status.Code(err).String()
+		ssoDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+		if err != nil {
+			ssoErrors.WithLabelValues(method, code).Inc()
+		}
+		return err
+	}
+}
+
+// ObserveImport учитывает завершённую задачу импорта
+func ObserveImport(format string, status string, duration time.Duration, imported int, failed int) {
+	importDuration.WithLabelValues(format, status).Observe(duration.Seconds())
+	importRows.WithLabelValues(format, "imported").Add(float64(imported))
+	importRows.WithLabelValues(format, "failed").Add(float64(failed))
+}
+
+// ObserveSearch учитывает поисковый запрос и отдельно — запросы без результатов
+func ObserveSearch(results int) {
+	searchQueries.Inc()
+	if results == 0 {
+		searchZeroResults.Inc()
+	}
+}
+
+// RegisterDBStats публикует состояние пула соединений с БД
+func RegisterDBStats(stats func() sql.DBStats) {
+	gauge := func(name string, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "db", Name: name, Help: help,
+		}, func() float64 { return value(stats()) })
+	}
+	counter := func(name string, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "db", Name: name, Help: help,
+		}, func() float64 { return value(stats()) })
+	}
+
+	Registry.MustRegister(
+		gauge("max_open_connections", "Наибольшее число открытых соединений.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("open_connections", "Открытые соединения.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("in_use_connections", "Занятые соединения.", func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("idle_connections", "Свободные соединения.", func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("wait_count_total", "Сколько раз ждали свободного соединения.", func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("wait_duration_seconds_total", "Суммарное время ожидания соединения.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+		counter("max_idle_closed_total", "Соединения, закрытые из-за лимита свободных.", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }),
+		counter("max_lifetime_closed_total", "Соединения, закрытые по времени жизни.", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }),
+	)
+}
+
+// RegisterRoleCacheStats публикует счётчики кеша ролей
+func RegisterRoleCacheStats(stats func() rolecache.Stats) {
+	counter := func(name string, help string, value func(rolecache.Stats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "role_cache", Name: name, Help: help,
+		}, func() float64 { return value(stats()) })
+	}
+
+	Registry.MustRegister(
+		counter("hits_total", "Роли, найденные в кеше.", func(s rolecache.Stats) float64 { return float64(s.Hits) }),
+		counter("misses_total", "Роли, запрошенные у SSO.", func(s rolecache.Stats) float64 { return float64(s.Misses) }),
+		counter("errors_total", "Ошибки запроса роли у SSO.", func(s rolecache.Stats) float64 { return float64(s.Errors) }),
+		counter("fallbacks_total", "Ответы устаревшей ролью из кеша при недоступном SSO.", func(s rolecache.Stats) float64 { return float64(s.Fallbacks) }),
+		counter("evictions_total", "Записи, вытесненные из переполненного кеша.", func(s rolecache.Stats) float64 { return float64(s.Evictions) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "role_cache", Name: "size", Help: "Записи в кеше.",
+		}, func() float64 { return float64(stats().Size) }),
+	)
+}
+
+func statusLabel(status int) string {
+	// Обработчик не вызвал WriteHeader — net/http ответит 200
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return strconv.Itoa(status)
+}
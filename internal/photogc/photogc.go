@@ -0,0 +1,62 @@
+// Package photogc удаляет из BlobStore фотографии, на которые больше не ссылаются
+package photogc
+
+import (
+	"context"
+	"log/slog"
+	"telephone-book/internal/config"
+	"telephone-book/internal/lib/logger/sl"
+	"time"
+)
+
+// batchSize сколько отметок читать из БД за раз
+const batchSize = 100
+
+type OrphanDeleter interface {
+	DeleteOrphanPhotos(ctx context.Context, grace time.Duration, batchSize int) (int, error)
+}
+
+// Collector периодически удаляет фотографии, отмеченные хранилищем как ненужные
+type Collector struct {
+	log      *slog.Logger
+	storage  OrphanDeleter
+	interval time.Duration
+	grace    time.Duration
+}
+
+func New(log *slog.Logger, storage OrphanDeleter, cfg config.Blob) *Collector {
+	return &Collector{
+		log:      log,
+		storage:  storage,
+		interval: max(cfg.GCInterval, time.Minute),
+		grace:    cfg.OrphanGrace,
+	}
+}
+
+// Start удаляет фотографии в фоне, пока не отменён ctx
+func (c *Collector) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.collect(ctx)
+			}
+		}
+	}()
+}
+
+func (c *Collector) collect(ctx context.Context) {
+	deleted, err := c.storage.DeleteOrphanPhotos(ctx, c.grace, batchSize)
+	if deleted > 0 {
+		c.log.Info("orphan photos deleted", slog.Int("count", deleted))
+	}
+	// Необработанные отметки остаются до следующего прохода
+	if err != nil {
+		c.log.Warn("failed to delete orphan photos", sl.Err(err))
+	}
+}
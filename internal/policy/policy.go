@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage"
+)
+
+// Action действие, которое проверяет политика
+type Action string
+
+const (
+	// Работники отдела: создание, изменение любых полей, удаление
+	ActionWorkerCreate Action = "worker.create"
+	ActionWorkerUpdate Action = "worker.update"
+	ActionWorkerDelete Action = "worker.delete"
+	// Замена, кадрирование и удаление фото без модерации
+	ActionPhotoWrite Action = "photo.write"
+
+	// Разрешены любому вошедшему пользователю: заполнить пустые поля работника
+	// и отправить фото (на модерацию, если у пользователя нет photo.write)
+	ActionWorkerFill  Action = "worker.fill"
+	ActionPhotoSubmit Action = "photo.submit"
+
+	// Действия над институтом целиком; право на отдел их не даёт
+	ActionImport           Action = "import"
+	ActionPhotoModerate    Action = "photo.moderate"
+	ActionDepartmentManage Action = "department.manage"
+	ActionPermissionManage Action = "permission.manage"
+
+	// Действия над сервисом целиком (scope пустой): разрешены только администратору SSO
+	ActionAPIKeyManage  Action = "apikey.manage"
+	ActionSessionRevoke Action = "session.revoke"
+)
+
+// Роли в таблице прав
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+var roleActions = map[string]map[Action]bool{
+	RoleEditor: {
+		ActionWorkerCreate: true,
+		ActionWorkerUpdate: true,
+		ActionWorkerDelete: true,
+		ActionPhotoWrite:   true,
+		ActionImport:       true,
+	},
+	RoleAdmin: {
+		ActionWorkerCreate:     true,
+		ActionWorkerUpdate:     true,
+		ActionWorkerDelete:     true,
+		ActionPhotoWrite:       true,
+		ActionImport:           true,
+		ActionPhotoModerate:    true,
+		ActionDepartmentManage: true,
+		ActionPermissionManage: true,
+	},
+}
+
+var authenticatedActions = map[Action]bool{
+	ActionWorkerFill:  true,
+	ActionPhotoSubmit: true,
+}
+
+var serviceActions = map[Action]bool{
+	ActionAPIKeyManage:  true,
+	ActionSessionRevoke: true,
+}
+
+// ValidRole можно ли выдать такую роль
+func ValidRole(role string) bool {
+	_, ok := roleActions[role]
+	return ok
+}
+
+// Scope объект действия: институт и, для работников, их отдел
+type Scope struct {
+	Institute  string
+	Department string
+}
+
+// Subject кто выполняет действие
+type Subject struct {
+	UserID int64
+	// SuperAdmin администратор в SSO: ему разрешено всё во всех институтах
+	SuperAdmin bool
+	// APIKey ключ сервиса, если запрос пришёл с ним вместо токена
+	APIKey *models.APIKey
+}
+
+type GrantStorage interface {
+	GetUserGrants(ctx context.Context, userID int64) ([]models.Grant, error)
+}
+
+// Policy единая точка проверки прав на изменяющие действия
+type Policy struct {
+	grants GrantStorage
+}
+
+func New(grants GrantStorage) *Policy {
+	return &Policy{grants: grants}
+}
+
+// Allowed разрешено ли subject выполнить action над scope.
+// Право на институт покрывает все его отделы; право на отдел — только работников
+// этого отдела, поэтому работники без отдела доступны лишь по праву на институт.
+func (p *Policy) Allowed(ctx context.Context, subject Subject, action Action, scope Scope) (bool, error) {
+	const op = "policy.Allowed"
+
+	if subject.SuperAdmin {
+		return true, nil
+	}
+	if serviceActions[action] {
+		return false, nil
+	}
+
+	institute, err := storage.Schema(scope.Institute)
+	if err != nil {
+		// Несуществующий институт: прав на него ни у кого нет
+		return false, nil
+	}
+
+	if subject.APIKey != nil {
+		return keyAllowed(*subject.APIKey, action, institute), nil
+	}
+	if subject.UserID == 0 {
+		return false, nil
+	}
+	if authenticatedActions[action] {
+		return true, nil
+	}
+
+	grants, err := p.grants.GetUserGrants(ctx, subject.UserID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, grant := range grants {
+		if grant.Institute != institute || !roleActions[grant.Role][action] {
+			continue
+		}
+		if grant.Department == "" || grant.Department == scope.Department {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// keyAllowed ключ на чтение и запись может в своих институтах то же, что редактор института
+func keyAllowed(key models.APIKey, action Action, institute string) bool {
+	if key.ReadOnly() || !slices.Contains(key.Institutes, institute) {
+		return false
+	}
+	return authenticatedActions[action] || roleActions[RoleEditor][action]
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"telephone-book/internal/domain/models"
+	"testing"
+)
+
+type fakeGrants map[int64][]models.Grant
+
+func (g fakeGrants) GetUserGrants(_ context.Context, userID int64) ([]models.Grant, error) {
+	return g[userID], nil
+}
+
+const (
+	instituteAdmin  = 1
+	instituteEditor = 2
+	deptEditor      = 3
+	deptAdmin       = 4
+	noGrants        = 5
+)
+
+var testGrants = fakeGrants{
+	instituteAdmin:  {{Role: RoleAdmin, Institute: "grafit"}},
+	instituteEditor: {{Role: RoleEditor, Institute: "grafit"}},
+	deptEditor:      {{Role: RoleEditor, Institute: "grafit", Department: "Лаборатория"}},
+	deptAdmin:       {{Role: RoleAdmin, Institute: "grafit", Department: "Лаборатория"}},
+}
+
+func TestAllowed(t *testing.T) {
+	readKey := &models.APIKey{ID: 1, Access: models.APIKeyRead, Institutes: []string{"grafit"}}
+	writeKey := &models.APIKey{ID: 2, Access: models.APIKeyReadWrite, Institutes: []string{"grafit"}}
+
+	lab := Scope{Institute: "grafit", Department: "Лаборатория"}
+	office := Scope{Institute: "grafit", Department: "Бухгалтерия"}
+	noDept := Scope{Institute: "grafit"}
+	otherInstitute := Scope{Institute: "giredmet", Department: "Лаборатория"}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		scope   Scope
+		allowed bool
+	}{
+		// Права на институт покрывают все отделы и работников без отдела
+		{"institute editor in any department", Subject{UserID: instituteEditor}, ActionWorkerUpdate, office, true},
+		{"institute editor without department", Subject{UserID: instituteEditor}, ActionWorkerCreate, noDept, true},
+		{"institute editor other institute", Subject{UserID: instituteEditor}, ActionWorkerUpdate, otherInstitute, false},
+		{"institute editor institute action", Subject{UserID: instituteEditor}, ActionImport, noDept, true},
+		{"institute editor admin action", Subject{UserID: instituteEditor}, ActionDepartmentManage, noDept, false},
+		{"institute admin admin action", Subject{UserID: instituteAdmin}, ActionPermissionManage, noDept, true},
+		{"institute alias", Subject{UserID: instituteAdmin}, ActionPhotoModerate, Scope{Institute: "Графит"}, true},
+
+		// Права на отдел — только работники этого отдела
+		{"department editor own department", Subject{UserID: deptEditor}, ActionWorkerDelete, lab, true},
+		{"department editor other department", Subject{UserID: deptEditor}, ActionWorkerDelete, office, false},
+		{"department editor worker without department", Subject{UserID: deptEditor}, ActionWorkerUpdate, noDept, false},
+		{"department admin institute action", Subject{UserID: deptAdmin}, ActionDepartmentManage, noDept, false},
+		{"department admin photo in own department", Subject{UserID: deptAdmin}, ActionPhotoWrite, lab, true},
+
+		// Вошедшим пользователям без прав доступны только заполнение и отправка фото
+		{"user fill", Subject{UserID: noGrants}, ActionWorkerFill, office, true},
+		{"user submit photo", Subject{UserID: noGrants}, ActionPhotoSubmit, office, true},
+		{"user update", Subject{UserID: noGrants}, ActionWorkerUpdate, office, false},
+		{"guest fill", Subject{}, ActionWorkerFill, office, false},
+		{"unknown institute", Subject{UserID: instituteAdmin}, ActionWorkerUpdate, Scope{Institute: "nowhere"}, false},
+
+		// Ключ на чтение и запись — редактор своих институтов, ключ на чтение не может ничего
+		{"read-write key edit", Subject{APIKey: writeKey}, ActionWorkerUpdate, office, true},
+		{"read-write key import", Subject{APIKey: writeKey}, ActionImport, noDept, true},
+		{"read-write key fill", Subject{APIKey: writeKey}, ActionWorkerFill, office, true},
+		{"read-write key admin action", Subject{APIKey: writeKey}, ActionPhotoModerate, noDept, false},
+		{"read-write key other institute", Subject{APIKey: writeKey}, ActionWorkerUpdate, otherInstitute, false},
+		{"read-only key edit", Subject{APIKey: readKey}, ActionWorkerUpdate, office, false},
+		{"read-only key fill", Subject{APIKey: readKey}, ActionWorkerFill, office, false},
+
+		// Действия над сервисом — только администратору SSO
+		{"superadmin manages keys", Subject{UserID: noGrants, SuperAdmin: true}, ActionAPIKeyManage, Scope{}, true},
+		{"superadmin revokes sessions", Subject{UserID: noGrants, SuperAdmin: true}, ActionSessionRevoke, Scope{}, true},
+		{"institute admin manages keys", Subject{UserID: instituteAdmin}, ActionAPIKeyManage, Scope{}, false},
+		{"institute admin revokes sessions", Subject{UserID: instituteAdmin}, ActionSessionRevoke, noDept, false},
+		{"read-write key manages keys", Subject{APIKey: writeKey}, ActionAPIKeyManage, Scope{}, false},
+		{"superadmin any institute", Subject{UserID: noGrants, SuperAdmin: true}, ActionDepartmentManage, otherInstitute, true},
+	}
+
+	p := New(testGrants)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := p.Allowed(context.Background(), tt.subject, tt.action, tt.scope)
+			if err != nil {
+				t.Fatalf("Allowed: %v", err)
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("Allowed(%+v, %s, %+v) = %v, want %v", tt.subject, tt.action, tt.scope, allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+type failingGrants struct{}
+
+func (failingGrants) GetUserGrants(context.Context, int64) ([]models.Grant, error) {
+	return nil, errors.New("db is down")
+}
+
+// Ошибка чтения прав не превращается в отказ или разрешение молча
+func TestAllowedStorageError(t *testing.T) {
+	p := New(failingGrants{})
+
+	_, err := p.Allowed(context.Background(), Subject{UserID: instituteEditor}, ActionWorkerUpdate, Scope{Institute: "grafit"})
+	if err == nil {
+		t.Fatal("Allowed error = nil, want storage error")
+	}
+}
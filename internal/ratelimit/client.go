@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// ClientLimiter token bucket на каждого клиента: rps запросов в секунду, burst подряд
+type ClientLimiter struct {
+	rps   float64
+	burst float64
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewClientLimiter(rps float64, burst int) *ClientLimiter {
+	return &ClientLimiter{
+		rps:       rps,
+		burst:     float64(max(burst, 1)),
+		now:       time.Now,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow можно ли выполнить запрос клиента сейчас; иначе — через сколько повторить
+func (l *ClientLimiter) Allow(client string) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.rps <= 0 {
+		return false, time.Minute
+	}
+	return false, time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+}
+
+// sweep раз в минуту забывает клиентов с полным запасом: их состояние совпадает с новым
+func (l *ClientLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rps >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestClientLimiter(clock *fakeClock, rps float64, burst int) *ClientLimiter {
+	l := NewClientLimiter(rps, burst)
+	l.now = clock.Now
+	l.lastSweep = clock.Now()
+	return l
+}
+
+func TestClientLimiterBurstAndRefill(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestClientLimiter(clock, 2, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("ip:10.0.0.1"); !ok {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+
+	ok, retryAfter := l.Allow("ip:10.0.0.1")
+	if ok {
+		t.Fatal("request over burst allowed")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Fatalf("retryAfter = %v, want 500ms", retryAfter)
+	}
+
+	// Другой клиент считается отдельно
+	if ok, _ := l.Allow("ip:10.0.0.2"); !ok {
+		t.Fatal("other client rejected")
+	}
+
+	// За полсекунды при 2 rps набирается один запрос
+	clock.Advance(500 * time.Millisecond)
+	if ok, _ := l.Allow("ip:10.0.0.1"); !ok {
+		t.Fatal("request after refill rejected")
+	}
+	if ok, _ := l.Allow("ip:10.0.0.1"); ok {
+		t.Fatal("second request after refill of one token allowed")
+	}
+
+	// Запас не растёт выше burst
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("ip:10.0.0.1"); !ok {
+			t.Fatalf("request %d after long pause rejected", i+1)
+		}
+	}
+	if ok, _ := l.Allow("ip:10.0.0.1"); ok {
+		t.Fatal("request over burst after long pause allowed")
+	}
+}
+
+// Клиенты с полным запасом забываются, и их состояние совпадает с новым
+func TestClientLimiterSweep(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestClientLimiter(clock, 1, 2)
+
+	l.Allow("ip:10.0.0.1")
+	l.Allow("ip:10.0.0.2")
+	l.Allow("ip:10.0.0.2")
+
+	clock.Advance(time.Minute)
+	l.Allow("ip:10.0.0.3")
+
+	if _, ok := l.buckets["ip:10.0.0.1"]; ok {
+		t.Fatal("client with full bucket not swept")
+	}
+	if _, ok := l.buckets["ip:10.0.0.3"]; !ok {
+		t.Fatal("active client swept")
+	}
+}
+
+func TestClientLimiterZeroRate(t *testing.T) {
+	clock := newFakeClock()
+	l := newTestClientLimiter(clock, 0, 1)
+
+	if ok, _ := l.Allow("key:1"); !ok {
+		t.Fatal("first request rejected")
+	}
+	ok, retryAfter := l.Allow("key:1")
+	if ok || retryAfter != time.Minute {
+		t.Fatalf("Allow = %v, %v, want false, 1m", ok, retryAfter)
+	}
+}
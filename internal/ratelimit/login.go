@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"telephone-book/internal/config"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"time"
+)
+
+// Store счётчики неудачных входов: в памяти (MemoryStore) или в БД (postgresql.Storage)
+type Store interface {
+	GetLoginAttempts(ctx context.Context, key string) (models.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	DeleteStaleLoginAttempts(ctx context.Context, before time.Time) (int64, error)
+}
+
+// LoginGuard ограничивает перебор паролей отдельно по адресу клиента и по учётной записи
+type LoginGuard struct {
+	log   *slog.Logger
+	store Store
+	cfg   config.LoginLimit
+	now   func() time.Time
+}
+
+func NewLoginGuard(log *slog.Logger, store Store, cfg config.LoginLimit) *LoginGuard {
+	return &LoginGuard{
+		log:   log,
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Start в фоне удаляет устаревшие счётчики, пока не отменён ctx
+func (g *LoginGuard) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(max(g.cfg.Window, time.Minute))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := g.store.DeleteStaleLoginAttempts(ctx, g.now().Add(-g.cfg.Window)); err != nil {
+					g.log.Warn("failed to delete stale login attempts", sl.Err(err))
+				}
+			}
+		}
+	}()
+}
+
+// Check сколько ещё ждать до следующей попытки входа; 0 — можно входить
+func (g *LoginGuard) Check(ctx context.Context, ip string, email string) (time.Duration, error) {
+	const op = "ratelimit.LoginGuard.Check"
+
+	now := g.now()
+	var wait time.Duration
+
+	for _, key := range []string{ipKey(ip), accountKey(email)} {
+		attempts, err := g.store.GetLoginAttempts(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		wait = max(wait, attempts.LockedUntil.Sub(now))
+	}
+
+	return wait, nil
+}
+
+// Failure учитывает неудачный вход и при превышении порога блокирует вход
+func (g *LoginGuard) Failure(ctx context.Context, ip string, email string) error {
+	const op = "ratelimit.LoginGuard.Failure"
+
+	now := g.now()
+
+	limits := []struct {
+		kind     string
+		key      string
+		attempts int
+	}{
+		{"ip", ipKey(ip), g.cfg.IPAttempts},
+		{"account", accountKey(email), g.cfg.AccountAttempts},
+	}
+
+	for _, limit := range limits {
+		failures, err := g.store.RecordLoginFailure(ctx, limit.key, now, g.cfg.Window)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if failures < limit.attempts {
+			continue
+		}
+
+		lockout := g.lockout(failures - limit.attempts)
+		if err := g.store.LockLogin(ctx, limit.key, now.Add(lockout)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		// В ключе почта или адрес; по хешу записи об одной блокировке можно связать между собой
+		g.log.Warn("login locked",
+			slog.String("limit", limit.kind),
+			slog.String("key_hash", keyHash(limit.key)),
+			slog.Int("failures", failures),
+			slog.Duration("lockout", lockout),
+		)
+	}
+
+	return nil
+}
+
+// Success сбрасывает счётчик учётной записи. Счётчик адреса не сбрасывается:
+// иначе вход в свою учётную запись открывал бы перебор чужих с того же адреса
+func (g *LoginGuard) Success(ctx context.Context, email string) error {
+	const op = "ratelimit.LoginGuard.Success"
+
+	if err := g.store.ResetLoginAttempts(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockout BaseLockout, удвоенная за каждую неудачу сверх порога, но не больше MaxLockout
+func (g *LoginGuard) lockout(extra int) time.Duration {
+	lockout := g.cfg.BaseLockout
+	for i := 0; i < extra && lockout < g.cfg.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, g.cfg.MaxLockout)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// keyHash короткий хеш ключа для логов
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"telephone-book/internal/config"
+	"testing"
+	"time"
+)
+
+// fakeClock время, которое тест двигает сам
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+var testLoginLimit = config.LoginLimit{
+	AccountAttempts: 3,
+	IPAttempts:      5,
+	Window:          15 * time.Minute,
+	BaseLockout:     30 * time.Second,
+	MaxLockout:      4 * time.Minute,
+}
+
+func newTestGuard(clock *fakeClock) *LoginGuard {
+	g := NewLoginGuard(slog.New(slog.NewTextHandler(io.Discard, nil)), NewMemoryStore(), testLoginLimit)
+	g.now = clock.Now
+	return g
+}
+
+func checkWait(t *testing.T, g *LoginGuard, ip string, email string, want time.Duration) {
+	t.Helper()
+
+	wait, err := g.Check(context.Background(), ip, email)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if wait != want {
+		t.Fatalf("Check(%s, %s) = %v, want %v", ip, email, wait, want)
+	}
+}
+
+func fail(t *testing.T, g *LoginGuard, ip string, email string) {
+	t.Helper()
+
+	if err := g.Failure(context.Background(), ip, email); err != nil {
+		t.Fatalf("Failure: %v", err)
+	}
+}
+
+// Блокировка начинается с порога и удваивается за каждую следующую неудачу до MaxLockout
+func TestLoginGuardExponentialLockout(t *testing.T) {
+	clock := newFakeClock()
+	g := newTestGuard(clock)
+
+	// Каждая попытка с нового адреса, чтобы считался только счётчик учётной записи
+	want := []time.Duration{0, 0, 30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, lockout := range want {
+		fail(t, g, fmt.Sprintf("10.0.0.%d", i+1), "ivanov@example.com")
+		checkWait(t, g, "192.168.0.1", "ivanov@example.com", lockout)
+	}
+
+	// Блокировка проходит сама
+	clock.Advance(4 * time.Minute)
+	checkWait(t, g, "192.168.0.1", "ivanov@example.com", 0)
+}
+
+func TestLoginGuardWindow(t *testing.T) {
+	clock := newFakeClock()
+	g := newTestGuard(clock)
+
+	fail(t, g, "10.0.0.1", "ivanov@example.com")
+	fail(t, g, "10.0.0.1", "ivanov@example.com")
+
+	// После окна без неудач счётчик начинается заново, и третья неудача ещё не блокирует
+	clock.Advance(testLoginLimit.Window + time.Second)
+	fail(t, g, "10.0.0.1", "ivanov@example.com")
+	checkWait(t, g, "10.0.0.1", "ivanov@example.com", 0)
+}
+
+// Перебор учётных записей с одного адреса блокирует адрес, а перебор пароля одной
+// учётной записи с разных адресов — учётную запись
+func TestLoginGuardIPAndAccountKeys(t *testing.T) {
+	clock := newFakeClock()
+	g := newTestGuard(clock)
+
+	for i := 0; i < testLoginLimit.IPAttempts; i++ {
+		fail(t, g, "10.0.0.1", fmt.Sprintf("user%d@example.com", i))
+	}
+	checkWait(t, g, "10.0.0.1", "other@example.com", 30*time.Second)
+	checkWait(t, g, "10.0.0.2", "user0@example.com", 0)
+
+	for i := 0; i < testLoginLimit.AccountAttempts; i++ {
+		fail(t, g, fmt.Sprintf("10.0.1.%d", i+1), "petrov@example.com")
+	}
+	checkWait(t, g, "10.0.2.1", "petrov@example.com", 30*time.Second)
+
+	// Почта сравнивается без учёта регистра и пробелов
+	checkWait(t, g, "10.0.2.1", " Petrov@Example.com ", 30*time.Second)
+}
+
+// Успешный вход сбрасывает счётчик учётной записи, но не адреса
+func TestLoginGuardSuccessResetsAccount(t *testing.T) {
+	clock := newFakeClock()
+	g := newTestGuard(clock)
+
+	for i := 0; i < testLoginLimit.AccountAttempts; i++ {
+		fail(t, g, "10.0.0.1", "ivanov@example.com")
+	}
+	checkWait(t, g, "10.0.0.2", "ivanov@example.com", 30*time.Second)
+
+	if err := g.Success(context.Background(), "ivanov@example.com"); err != nil {
+		t.Fatalf("Success: %v", err)
+	}
+	checkWait(t, g, "10.0.0.2", "ivanov@example.com", 0)
+
+	// Счётчик адреса продолжается: ещё две неудачи с него доводят его до порога
+	fail(t, g, "10.0.0.1", "sidorov@example.com")
+	fail(t, g, "10.0.0.1", "sidorov@example.com")
+	checkWait(t, g, "10.0.0.1", "kuznetsov@example.com", 30*time.Second)
+}
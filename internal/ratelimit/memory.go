@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"telephone-book/internal/domain/models"
+	"time"
+)
+
+// MemoryStore счётчики неудачных входов в памяти; годится для одного экземпляра сервиса
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]models.LoginAttempts)}
+}
+
+func (s *MemoryStore) GetLoginAttempts(_ context.Context, key string) (models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts, ok := s.attempts[key]
+	if !ok {
+		return models.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+func (s *MemoryStore) RecordLoginFailure(_ context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := s.attempts[key]
+	attempts.Key = key
+	if attempts.LastFailure.Before(now.Add(-window)) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = now
+	s.attempts[key] = attempts
+
+	return attempts.Failures, nil
+}
+
+func (s *MemoryStore) LockLogin(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := s.attempts[key]
+	attempts.Key = key
+	attempts.LockedUntil = until
+	s.attempts[key] = attempts
+
+	return nil
+}
+
+func (s *MemoryStore) ResetLoginAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteStaleLoginAttempts(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for key, attempts := range s.attempts {
+		if attempts.LastFailure.Before(before) && attempts.LockedUntil.Before(now) {
+			delete(s.attempts, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
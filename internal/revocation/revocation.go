@@ -0,0 +1,170 @@
+package revocation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/logger/sl"
+	"telephone-book/internal/lib/token"
+	"time"
+)
+
+type Storage interface {
+	RevokeToken(ctx context.Context, token models.RevokedToken) error
+	RevokeUserSessions(ctx context.Context, session models.RevokedSession, revokedBy int64) error
+	GetRevocations(ctx context.Context) ([]models.RevokedToken, []models.RevokedSession, error)
+	DeleteExpiredRevocations(ctx context.Context, maxTokenAge time.Duration) (int64, error)
+}
+
+// List список отозванных токенов. Источник истины — БД, проверка в каждом запросе
+// идёт по копии в памяти, которая периодически перечитывается: так отзыв,
+// сделанный на другом экземпляре сервиса, применяется не позже чем через refresh.
+type List struct {
+	log         *slog.Logger
+	storage     Storage
+	refresh     time.Duration
+	maxTokenAge time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	sessions map[int64]time.Time
+}
+
+// New создаёт список; maxTokenAge — время жизни токенов SSO с учётом расхождения часов
+func New(log *slog.Logger, storage Storage, refresh time.Duration, maxTokenAge time.Duration) *List {
+	return &List{
+		log:         log,
+		storage:     storage,
+		refresh:     max(refresh, time.Second),
+		maxTokenAge: maxTokenAge,
+		tokens:      make(map[string]time.Time),
+		sessions:    make(map[int64]time.Time),
+	}
+}
+
+// Start загружает список и перечитывает его в фоне, пока не отменён ctx
+func (l *List) Start(ctx context.Context) error {
+	const op = "revocation.Start"
+
+	if err := l.load(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	go l.loop(ctx)
+
+	return nil
+}
+
+func (l *List) loop(ctx context.Context) {
+	ticker := time.NewTicker(l.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.storage.DeleteExpiredRevocations(ctx, l.maxTokenAge); err != nil {
+				l.log.Warn("failed to delete expired revocations", sl.Err(err))
+			}
+			// При недоступной БД продолжаем работать со старой копией
+			if err := l.load(ctx); err != nil {
+				l.log.Error("failed to refresh revocation list", sl.Err(err))
+			}
+		}
+	}
+}
+
+func (l *List) load(ctx context.Context) error {
+	tokens, sessions, err := l.storage.GetRevocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokenMap := make(map[string]time.Time, len(tokens))
+	for _, t := range tokens {
+		tokenMap[t.Hash] = t.ExpiresAt
+	}
+
+	sessionMap := make(map[int64]time.Time, len(sessions))
+	for _, s := range sessions {
+		sessionMap[s.UserID] = s.RevokedBefore
+	}
+
+	l.mu.Lock()
+	l.tokens = tokenMap
+	l.sessions = sessionMap
+	l.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked отозван ли сам токен или все сессии его владельца
+func (l *List) IsRevoked(tokenString string, claims token.Claims) bool {
+	hash := Hash(tokenString)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.tokens[hash]; ok {
+		return true
+	}
+
+	// iat в токене с точностью до секунды; граница отзыва тоже округляется вниз,
+	// иначе токен, выданный в ту же секунду сразу после отзыва, считался бы отозванным
+	before, ok := l.sessions[claims.UserID]
+	return ok && claims.IssuedAt.Before(before.Truncate(time.Second))
+}
+
+// RevokeToken отзывает один токен (выход из системы)
+func (l *List) RevokeToken(ctx context.Context, tokenString string, claims token.Claims) error {
+	const op = "revocation.RevokeToken"
+
+	revoked := models.RevokedToken{
+		Hash:      Hash(tokenString),
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+	}
+
+	if err := l.storage.RevokeToken(ctx, revoked); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.mu.Lock()
+	l.tokens[revoked.Hash] = revoked.ExpiresAt
+	l.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser отзывает все уже выданные токены пользователя
+func (l *List) RevokeUser(ctx context.Context, userID int64, revokedBy int64) error {
+	const op = "revocation.RevokeUser"
+
+	session := models.RevokedSession{
+		UserID:        userID,
+		RevokedBefore: time.Now().Truncate(time.Second),
+	}
+
+	if err := l.storage.RevokeUserSessions(ctx, session, revokedBy); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	l.mu.Lock()
+	if session.RevokedBefore.After(l.sessions[userID]) {
+		l.sessions[userID] = session.RevokedBefore
+	}
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Hash ключ токена в списке; сами токены не хранятся
+func Hash(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(sum[:])
+}
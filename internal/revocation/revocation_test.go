@@ -0,0 +1,125 @@
+package revocation
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/lib/token"
+	"testing"
+	"time"
+)
+
+type fakeStorage struct {
+	tokens   []models.RevokedToken
+	sessions []models.RevokedSession
+}
+
+func (s *fakeStorage) RevokeToken(_ context.Context, token models.RevokedToken) error {
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func (s *fakeStorage) RevokeUserSessions(_ context.Context, session models.RevokedSession, _ int64) error {
+	s.sessions = append(s.sessions, session)
+	return nil
+}
+
+func (s *fakeStorage) GetRevocations(context.Context) ([]models.RevokedToken, []models.RevokedSession, error) {
+	return s.tokens, s.sessions, nil
+}
+
+func (s *fakeStorage) DeleteExpiredRevocations(context.Context, time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func newTestList(storage Storage) *List {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), storage, time.Minute, time.Hour)
+}
+
+// iat токена с точностью до секунды: токен, выданный в ту же секунду, что и отзыв,
+// считается выданным после него, а выданный секундой раньше — отозванным
+func TestIsRevokedSessionSecondPrecision(t *testing.T) {
+	const userID = 7
+
+	// Граница из БД может прийти с долями секунды, если отзыв записал другой экземпляр
+	revokedAt := time.Date(2026, 3, 1, 12, 0, 0, 700_000_000, time.UTC)
+	storage := &fakeStorage{sessions: []models.RevokedSession{{UserID: userID, RevokedBefore: revokedAt}}}
+
+	l := newTestList(storage)
+	if err := l.load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   int64
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"issued a second earlier", userID, revokedAt.Truncate(time.Second).Add(-time.Second), true},
+		{"issued in the same second", userID, revokedAt.Truncate(time.Second), false},
+		{"issued later", userID, revokedAt.Add(time.Minute).Truncate(time.Second), false},
+		{"other user", userID + 1, revokedAt.Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := token.Claims{UserID: tt.userID, IssuedAt: tt.issuedAt}
+			if got := l.IsRevoked("token-"+tt.name, claims); got != tt.revoked {
+				t.Fatalf("IsRevoked = %v, want %v", got, tt.revoked)
+			}
+		})
+	}
+}
+
+// Токен, полученный при входе сразу после отзыва всех сессий, продолжает работать
+func TestRevokeUserKeepsTokenIssuedInSameSecond(t *testing.T) {
+	const userID = 7
+
+	storage := &fakeStorage{}
+	l := newTestList(storage)
+
+	before := time.Now().Truncate(time.Second)
+	if err := l.RevokeUser(context.Background(), userID, 1); err != nil {
+		t.Fatalf("RevokeUser: %v", err)
+	}
+
+	if len(storage.sessions) != 1 {
+		t.Fatalf("sessions saved = %d, want 1", len(storage.sessions))
+	}
+	revokedBefore := storage.sessions[0].RevokedBefore
+	if revokedBefore.Nanosecond() != 0 || revokedBefore.Before(before) {
+		t.Fatalf("RevokedBefore = %v, want whole second not before %v", revokedBefore, before)
+	}
+
+	fresh := token.Claims{UserID: userID, IssuedAt: revokedBefore}
+	if l.IsRevoked("fresh", fresh) {
+		t.Fatal("token issued in the second of revocation is revoked")
+	}
+
+	old := token.Claims{UserID: userID, IssuedAt: revokedBefore.Add(-time.Second)}
+	if !l.IsRevoked("old", old) {
+		t.Fatal("token issued before revocation is not revoked")
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	storage := &fakeStorage{}
+	l := newTestList(storage)
+
+	claims := token.Claims{UserID: 7, IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := l.RevokeToken(context.Background(), "revoked", claims); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+
+	if !l.IsRevoked("revoked", claims) {
+		t.Fatal("revoked token is not revoked")
+	}
+	if l.IsRevoked("other", claims) {
+		t.Fatal("other token of the same user is revoked")
+	}
+	if len(storage.tokens) != 1 || storage.tokens[0].Hash != Hash("revoked") {
+		t.Fatalf("saved tokens = %+v, want hash of revoked token", storage.tokens)
+	}
+}
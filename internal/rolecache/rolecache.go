@@ -0,0 +1,184 @@
+package rolecache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdminChecker источник ролей, обычно клиент SSO
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userID int64) (bool, error)
+}
+
+// Stats счётчики кеша с момента запуска
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Errors    uint64 `json:"errors"`
+	Fallbacks uint64 `json:"fallbacks"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+type key struct {
+	userID  int64
+	tokenID string
+}
+
+type entry struct {
+	key       key
+	isAdmin   bool
+	expiresAt time.Time
+}
+
+// Cache кеширует ответы IsAdmin по паре пользователь+токен на ttl.
+// Размер ограничен: при переполнении вытесняются давно не использованные токены.
+// Последняя известная роль пользователя хранится дольше ttl и используется,
+// когда SSO недоступен.
+type Cache struct {
+	checker AdminChecker
+	ttl     time.Duration
+	size    int
+
+	mu        sync.Mutex
+	entries   map[key]*list.Element
+	order     *list.List
+	lastKnown map[int64]bool
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	errors    atomic.Uint64
+	fallbacks atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func New(checker AdminChecker, ttl time.Duration, size int) *Cache {
+	return &Cache{
+		checker:   checker,
+		ttl:       ttl,
+		size:      max(size, 1),
+		entries:   make(map[key]*list.Element),
+		order:     list.New(),
+		lastKnown: make(map[int64]bool),
+	}
+}
+
+// IsAdmin возвращает роль из кеша или запрашивает её у SSO.
+// fallback = true означает, что SSO не ответил и роль взята из последнего известного значения.
+func (c *Cache) IsAdmin(ctx context.Context, userID int64, tokenID string) (isAdmin bool, fallback bool, err error) {
+	const op = "rolecache.IsAdmin"
+
+	k := key{userID: userID, tokenID: tokenID}
+
+	c.mu.Lock()
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expiresAt) {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return e.isAdmin, false, nil
+		}
+	}
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+
+	isAdmin, err = c.checker.IsAdmin(ctx, userID)
+	if err != nil {
+		c.errors.Add(1)
+
+		c.mu.Lock()
+		last, ok := c.lastKnown[userID]
+		c.mu.Unlock()
+
+		if ok {
+			c.fallbacks.Add(1)
+			return last, true, nil
+		}
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.put(k, isAdmin)
+
+	return isAdmin, false, nil
+}
+
+func (c *Cache) put(k key, isAdmin bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastKnown[k.userID] = isAdmin
+
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*entry)
+		e.isAdmin = isAdmin
+		e.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[k] = c.order.PushFront(&entry{key: k, isAdmin: isAdmin, expiresAt: time.Now().Add(c.ttl)})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.removeElement(oldest)
+		c.evictions.Add(1)
+	}
+
+	// lastKnown ограничиваем тем же размером; лишнего пользователя выбрасываем любого
+	for userID := range c.lastKnown {
+		if len(c.lastKnown) <= c.size {
+			break
+		}
+		delete(c.lastKnown, userID)
+	}
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+	delete(c.entries, e.key)
+}
+
+// Invalidate удаляет роль одного токена, например при выходе
+func (c *Cache) Invalidate(userID int64, tokenID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key{userID: userID, tokenID: tokenID}]; ok {
+		c.removeElement(el)
+	}
+}
+
+// InvalidateUser удаляет роли всех токенов пользователя и его последнюю известную роль
+func (c *Cache) InvalidateUser(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, el := range c.entries {
+		if k.userID == userID {
+			c.removeElement(el)
+		}
+	}
+	delete(c.lastKnown, userID)
+}
+
+// Stats текущие счётчики кеша
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Errors:    c.errors.Load(),
+		Fallbacks: c.fallbacks.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
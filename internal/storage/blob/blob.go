@@ -0,0 +1,36 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telephone-book/internal/config"
+)
+
+const (
+	DriverFS = "fs"
+	DriverS3 = "s3"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore хранилище двоичных объектов (фотографий) по ключу
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище, выбранное в конфиге
+func New(ctx context.Context, cfg config.Blob) (BlobStore, error) {
+	const op = "storage.blob.New"
+
+	switch cfg.Driver {
+	case DriverFS:
+		return NewFS(cfg.Path)
+	case DriverS3:
+		return NewS3(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("%s: unknown blob driver %q", op, cfg.Driver)
+	}
+}
@@ -0,0 +1,101 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS хранит объекты файлами в локальном каталоге; ключ — относительный путь
+type FS struct {
+	root string
+}
+
+func NewFS(root string) (*FS, error) {
+	const op = "storage.blob.NewFS"
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &FS{root: root}, nil
+}
+
+func (s *FS) Put(_ context.Context, key string, data []byte, _ string) error {
+	const op = "storage.blob.FS.Put"
+
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатели не увидели недописанный объект
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *FS) Get(_ context.Context, key string) ([]byte, error) {
+	const op = "storage.blob.FS.Get"
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func (s *FS) Delete(_ context.Context, key string) error {
+	const op = "storage.blob.FS.Delete"
+
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// path не даёт ключу выйти за пределы каталога хранилища
+func (s *FS) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
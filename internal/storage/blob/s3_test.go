@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"telephone-book/internal/config"
+	"testing"
+	"time"
+)
+
+// newTestS3 подключается к MinIO из docker-compose.yaml; без S3_TEST_ENDPOINT тест пропускается
+func newTestS3(t *testing.T) *S3 {
+	t.Helper()
+
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+
+	cfg := config.S3{
+		Endpoint:  endpoint,
+		AccessKey: envOr("S3_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("S3_SECRET_KEY", "minioadmin"),
+		Bucket:    fmt.Sprintf("telephone-book-test-%d", time.Now().UnixNano()),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := NewS3(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.client.RemoveBucket(context.Background(), store.bucket)
+	})
+
+	return store
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	store := newTestS3(t)
+	ctx := context.Background()
+
+	key := "grafit/photos/ab/abcdef"
+	data := []byte("\xff\xd8\xff photo")
+
+	if err := store.Put(ctx, key, data, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Get = %q, want %q", got, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+
+	// Повторное удаление не ошибка: фото могли удалить параллельно
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
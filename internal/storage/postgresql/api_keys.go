@@ -0,0 +1,161 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage"
+	"telephone-book/internal/tracing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, name, prefix, access, institutes, created_by, created_at, last_used_at, revoked_at`
+
+// CreateAPIKey сохраняет ключ API по его хешу
+func (s *Storage) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	const op = "storage.postgresql.api_keys.CreateAPIKey"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		INSERT INTO public.api_keys (name, key_hash, prefix, access, institutes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query,
+		key.Name,
+		hash,
+		key.Prefix,
+		key.Access,
+		pq.Array(key.Institutes),
+		sql.NullInt64{Int64: key.CreatedBy, Valid: key.CreatedBy != 0},
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return key, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyByHash возвращает действующий (не отозванный) ключ по хешу
+func (s *Storage) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	const op = "storage.postgresql.api_keys.GetAPIKeyByHash"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM public.api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return key, storage.ErrAPIKeyNotFound
+		}
+		return key, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeys все ключи, включая отозванные
+func (s *Storage) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "storage.postgresql.api_keys.GetAPIKeys"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM public.api_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan api key: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ; отозванный ключ остаётся в списке
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int64) error {
+	const op = "storage.postgresql.api_keys.RevokeAPIKey"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, `UPDATE public.api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey запоминает время последнего использования ключа
+func (s *Storage) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	const op = "storage.postgresql.api_keys.TouchAPIKey"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `UPDATE public.api_keys SET last_used_at = GREATEST(COALESCE(last_used_at, $2), $2) WHERE id = $1`
+
+	if _, err := s.db.ExecContext(ctx, query, id, usedAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var (
+		key        models.APIKey
+		institutes pq.StringArray
+		createdBy  sql.NullInt64
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Access,
+		&institutes,
+		&createdBy,
+		&key.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return key, err
+	}
+
+	key.Institutes = []string(institutes)
+	key.CreatedBy = createdBy.Int64
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telephone-book/internal/tracing"
+)
+
+// IsBootstrapped создан ли уже первый администратор
+func (s *Storage) IsBootstrapped(ctx context.Context) (bool, error) {
+	const op = "storage.postgresql.bootstrap.IsBootstrapped"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM public.bootstrap)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+// CompleteBootstrap запоминает первого администратора; повторные вызовы ничего не меняют
+func (s *Storage) CompleteBootstrap(ctx context.Context, email string, userID int64, method string) error {
+	const op = "storage.postgresql.bootstrap.CompleteBootstrap"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		INSERT INTO public.bootstrap (admin_email, admin_user_id, method)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (singleton) DO NOTHING`
+
+	_, err := s.db.ExecContext(ctx, query, email, sql.NullInt64{Int64: userID, Valid: userID != 0}, method)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
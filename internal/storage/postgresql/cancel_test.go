@@ -0,0 +1,65 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newMockStorage(t *testing.T) (*Storage, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Storage{db: db}, mock
+}
+
+// Запрос, отменённый клиентом, не доходит до БД
+func TestSearchCancelledContext(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Search(ctx, "grafit", "", "", "Иванов")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Search error = %v, want context.Canceled", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Срок запроса истекает, пока БД выполняет запрос: запрос прерывается, а не дожидается ответа
+func TestSearchDeadlineAbortsQuery(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectExec(`SET search_path TO "grafit"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .* FROM workers`).
+		WillDelayFor(5 * time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := s.Search(ctx, "grafit", "", "", "Иванов")
+	// Драйвер, как и lib/pq, сообщает об отмене своей ошибкой, а не ctx.Err();
+	// поэтому resp.FromError смотрит на контекст запроса
+	if !errors.Is(err, sqlmock.ErrCancelled) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Search error = %v, want the query to be cancelled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Search returned after %s, the query was not aborted", elapsed)
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("ctx.Err() = %v, want context.DeadlineExceeded", ctx.Err())
+	}
+}
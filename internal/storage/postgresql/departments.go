@@ -2,8 +2,10 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"telephone-book/internal/domain/models"
+	"telephone-book/internal/tracing"
 
 	_ "github.com/lib/pq"
 )
@@ -11,6 +13,9 @@ import (
 func (s *Storage) CreateDepartment(ctx context.Context, institute string, name string, sections []string) (int, error) {
 	const op = "storage.postgresql.departments.CreateDepartment"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	// Начинаем транзакцию
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -18,7 +23,7 @@ func (s *Storage) CreateDepartment(ctx context.Context, institute string, name s
 	}
 	defer tx.Rollback() // Убедимся, что транзакция будет откачена в случае ошибки
 
-	if err := s.SetSchema(ctx, institute); err != nil {
+	if err := setTxSchema(ctx, tx, institute); err != nil {
 		return emptyID, err
 	}
 
@@ -48,37 +53,41 @@ func (s *Storage) CreateDepartment(ctx context.Context, institute string, name s
 func (s *Storage) GetAllDepartments(ctx context.Context, institute string) ([]models.Department, error) {
 	const op = "storage.postgresql.departments.GetAllDepartments"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return nil, err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	var query string
+	query := `SELECT id, name FROM departments`
 
-	query = `SELECT id, name FROM departments`
+	var departments []models.Department
+	err := s.withSchema(ctx, institute, true, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 
-	rows, err := s.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	defer rows.Close()
+		for rows.Next() {
+			var department models.Department
 
-	var departments []models.Department
-	for rows.Next() {
-		var department models.Department
+			err := rows.Scan(
+				&department.ID,
+				&department.Name,
+			)
 
-		err := rows.Scan(
-			&department.ID,
-			&department.Name,
-		)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			departments = append(departments, department)
+		}
 
-		if err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
 		}
-		departments = append(departments, department)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return departments, nil
@@ -87,13 +96,15 @@ func (s *Storage) GetAllDepartments(ctx context.Context, institute string) ([]mo
 func (s *Storage) GetDepartmentID(ctx context.Context, institute string, name string) (int, error) {
 	const op = "storage.postgresql.departments.GetDepartmnetID"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return emptyID, err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	query := `SELECT id FROM departments WHERE name = $1`
 	var id int
-	err := s.db.QueryRowContext(ctx, query, name).Scan(&id)
+	err := s.withSchema(ctx, institute, true, func(tx *sql.Tx) error {
+		var err error
+		id, err = departmentID(ctx, tx, name)
+		return err
+	})
 	if err != nil {
 		return emptyID, fmt.Errorf("%s: %w", op, err)
 	}
@@ -101,43 +112,85 @@ func (s *Storage) GetDepartmentID(ctx context.Context, institute string, name st
 	return id, nil
 }
 
+// departmentID id отдела в схеме транзакции
+func departmentID(ctx context.Context, tx *sql.Tx, name string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM departments WHERE name = $1`, name).Scan(&id)
+	return id, err
+}
+
 func (s *Storage) GetSections(ctx context.Context, institute string, department string) ([]models.Section, error) {
 	const op = "storage.postgresql.departments.GetSections"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return nil, err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	parentID, err := s.GetDepartmentID(ctx, institute, department)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to get department ID: %w", op, err)
-	}
+	var sections []models.Section
+	err := s.withSchema(ctx, institute, true, func(tx *sql.Tx) error {
+		parentID, err := departmentID(ctx, tx, department)
+		if err != nil {
+			return fmt.Errorf("failed to get department ID: %w", err)
+		}
+
+		query := `SELECT id, name FROM sections WHERE parent_id = $1`
+		rows, err := tx.QueryContext(ctx, query, parentID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var section models.Section
+			err := rows.Scan(&section.ID, &section.Name)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			sections = append(sections, section)
+		}
 
-	query := `SELECT id, name FROM sections WHERE parent_id = $1`
-	rows, err := s.db.QueryContext(ctx, query, parentID)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows error: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
-	var sections []models.Section
-	for rows.Next() {
-		var section models.Section
-		err := rows.Scan(&section.ID, &section.Name)
+
+	return sections, nil
+}
+
+// CreateSection добавляет секцию в существующий отдел
+func (s *Storage) CreateSection(ctx context.Context, institute string, department string, name string) (int, error) {
+	const op = "storage.postgresql.departments.CreateSection"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var id int
+	err := s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		parentID, err := departmentID(ctx, tx, department)
 		if err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+			return fmt.Errorf("failed to get department ID: %w", err)
 		}
-		sections = append(sections, section)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+		query := `INSERT INTO sections (name, parent_id) VALUES ($1, $2) RETURNING id`
+		return tx.QueryRowContext(ctx, query, name, parentID).Scan(&id)
+	})
+	if err != nil {
+		return emptyID, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return sections, nil
+	return id, nil
 }
+
 func (s *Storage) DeleteDepartment(ctx context.Context, institute string, name string) error {
 	const op = "storage.postgresql.departments.DeleteDepartment"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	if err := s.SetSchema(ctx, institute); err != nil {
 		return err
 	}
@@ -155,6 +208,9 @@ func (s *Storage) DeleteDepartment(ctx context.Context, institute string, name s
 func (s *Storage) UpdateDepartment(ctx context.Context, institute string, oldName string, name string, sections []string) error {
 	const op = "storage.postgresql.departments.UpdateDepartment"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	// Начинаем транзакцию
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -162,7 +218,7 @@ func (s *Storage) UpdateDepartment(ctx context.Context, institute string, oldNam
 	}
 	defer tx.Rollback() // Убедимся, что транзакция будет откачена в случае ошибки
 
-	if err := s.SetSchema(ctx, institute); err != nil {
+	if err := setTxSchema(ctx, tx, institute); err != nil {
 		return err
 	}
 
@@ -0,0 +1,417 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage"
+	"telephone-book/internal/tracing"
+	"time"
+)
+
+// CreateImportJob сохраняет файл импорта в BlobStore и ставит задачу в очередь, если в ней
+// меньше queueSize задач, иначе возвращает ErrImportQueueFull. Возвращает задачу с заполненными
+// id, датами и ключом файла; выполнит её экземпляр, который первым заберёт её ClaimImportJob
+func (s *Storage) CreateImportJob(ctx context.Context, job models.ImportJob, payload []byte, queueSize int) (models.ImportJob, error) {
+	const op = "storage.postgresql.import_jobs.CreateImportJob"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	// Очередь проверяется до записи файла, чтобы не грузить его зря. Одновременные загрузки
+	// могут превысить лимит на несколько задач, это допустимо
+	var queued int
+	err := s.db.QueryRowContext(ctx, `SELECT count(*) FROM public.import_jobs WHERE status = $1`, models.ImportJobQueued).Scan(&queued)
+	if err != nil {
+		return job, fmt.Errorf("%s: %w", op, err)
+	}
+	if queued >= queueSize {
+		return job, storage.ErrImportQueueFull
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return job, fmt.Errorf("%s: failed to generate payload key: %w", op, err)
+	}
+	job.PayloadKey = "imports/" + hex.EncodeToString(suffix)
+
+	if err := s.blobs.Put(ctx, job.PayloadKey, payload, "application/octet-stream"); err != nil {
+		return job, fmt.Errorf("%s: failed to store payload: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO public.import_jobs (institute, format, status, missing_org_units, created_by, payload_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+		`
+
+	err = s.db.QueryRowContext(ctx, query,
+		job.Institute,
+		job.Format,
+		job.Status,
+		job.MissingOrgUnits,
+		sql.NullInt64{Int64: job.CreatedBy, Valid: job.CreatedBy != 0},
+		job.PayloadKey,
+	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		s.deletePayload(ctx, job.PayloadKey)
+		return job, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// ClaimImportJob забирает самую старую задачу из очереди: переводит её в running и отдаёт
+// в аренду экземпляру owner на срок lease. Пустая очередь — ErrImportJobNotFound.
+// SKIP LOCKED не даёт двум экземплярам забрать одну задачу
+func (s *Storage) ClaimImportJob(ctx context.Context, owner string, lease time.Duration) (models.ImportJob, error) {
+	const op = "storage.postgresql.import_jobs.ClaimImportJob"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		UPDATE public.import_jobs SET
+			status = $1,
+			owner = $2,
+			lease_until = now() + make_interval(secs => $3),
+			updated_at = now()
+		WHERE id = (
+			SELECT id FROM public.import_jobs
+			WHERE status = $4 AND payload_key IS NOT NULL
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + importJobColumns
+
+	job, err := scanImportJob(s.db.QueryRowContext(ctx, query,
+		models.ImportJobRunning,
+		owner,
+		lease.Seconds(),
+		models.ImportJobQueued,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, storage.ErrImportJobNotFound
+		}
+		return job, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// GetImportJobPayload читает файл импорта задачи из BlobStore
+func (s *Storage) GetImportJobPayload(ctx context.Context, key string) ([]byte, error) {
+	const op = "storage.postgresql.import_jobs.GetImportJobPayload"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	payload, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return payload, nil
+}
+
+// DeleteImportJobPayload удаляет файл импорта завершённой задачи
+func (s *Storage) DeleteImportJobPayload(ctx context.Context, key string) error {
+	const op = "storage.postgresql.import_jobs.DeleteImportJobPayload"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateImportJob сохраняет статус, прогресс и отчёт задачи. Сохраняет только владелец
+// незавершённой задачи: если задачу уже завершили как оборванную, возвращает ErrImportJobLost
+func (s *Storage) UpdateImportJob(ctx context.Context, job models.ImportJob) error {
+	const op = "storage.postgresql.import_jobs.UpdateImportJob"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rowErrors, err := json.Marshal(nonNilRowErrors(job.Errors))
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal errors: %w", op, err)
+	}
+
+	createdOrgUnits, err := json.Marshal(nonNilOrgUnits(job.CreatedOrgUnits))
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal created org units: %w", op, err)
+	}
+
+	query := `
+		UPDATE public.import_jobs SET
+			status = $1,
+			progress = $2,
+			total_rows = $3,
+			processed_rows = $4,
+			imported_rows = $5,
+			failed_rows = $6,
+			errors = $7,
+			error = $8,
+			finished_at = $9,
+			created_org_units = $10,
+			updated_at = now()
+		WHERE id = $11 AND owner = $12 AND finished_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query,
+		job.Status,
+		job.Progress,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.ImportedRows,
+		job.FailedRows,
+		rowErrors,
+		sql.NullString{String: job.Error, Valid: job.Error != ""},
+		job.FinishedAt,
+		createdOrgUnits,
+		job.ID,
+		job.Owner,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrImportJobLost
+	}
+
+	return nil
+}
+
+// importJobColumns колонки задачи импорта в порядке scanImportJob
+const importJobColumns = `id, institute, format, status, progress, total_rows, processed_rows,
+			imported_rows, failed_rows, errors, error, created_by, created_at, updated_at, finished_at,
+			missing_org_units, created_org_units, owner, cancel_requested, payload_key`
+
+// GetImportJob получает задачу импорта вместе с отчётом об ошибках
+func (s *Storage) GetImportJob(ctx context.Context, id int64) (models.ImportJob, error) {
+	const op = "storage.postgresql.import_jobs.GetImportJob"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + importJobColumns + ` FROM public.import_jobs WHERE id = $1`
+
+	job, err := scanImportJob(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, storage.ErrImportJobNotFound
+		}
+		return job, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// scanImportJob читает строку с колонками importJobColumns
+func scanImportJob(row *sql.Row) (models.ImportJob, error) {
+	var job models.ImportJob
+	var rowErrors, createdOrgUnits []byte
+	var jobError sql.NullString
+	var createdBy sql.NullInt64
+	var finishedAt sql.NullTime
+	var owner, payloadKey sql.NullString
+
+	err := row.Scan(
+		&job.ID,
+		&job.Institute,
+		&job.Format,
+		&job.Status,
+		&job.Progress,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.ImportedRows,
+		&job.FailedRows,
+		&rowErrors,
+		&jobError,
+		&createdBy,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+		&job.MissingOrgUnits,
+		&createdOrgUnits,
+		&owner,
+		&job.CancelRequested,
+		&payloadKey,
+	)
+	if err != nil {
+		return job, err
+	}
+
+	if err := json.Unmarshal(rowErrors, &job.Errors); err != nil {
+		return job, fmt.Errorf("failed to unmarshal errors: %w", err)
+	}
+
+	if err := json.Unmarshal(createdOrgUnits, &job.CreatedOrgUnits); err != nil {
+		return job, fmt.Errorf("failed to unmarshal created org units: %w", err)
+	}
+
+	job.Error = jobError.String
+	job.CreatedBy = createdBy.Int64
+	job.Owner = owner.String
+	job.PayloadKey = payloadKey.String
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return job, nil
+}
+
+// RenewImportJobLeases продлевает аренду незавершённых задач экземпляра owner и возвращает
+// id тех из них, отмену которых запросили из другого экземпляра
+func (s *Storage) RenewImportJobLeases(ctx context.Context, owner string, lease time.Duration) ([]int64, error) {
+	const op = "storage.postgresql.import_jobs.RenewImportJobLeases"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		UPDATE public.import_jobs SET
+			lease_until = now() + make_interval(secs => $2)
+		WHERE owner = $1 AND finished_at IS NULL
+		RETURNING id, cancel_requested`
+
+	rows, err := s.db.QueryContext(ctx, query, owner, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var cancelled []int64
+	for rows.Next() {
+		var id int64
+		var cancelRequested bool
+		if err := rows.Scan(&id, &cancelRequested); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if cancelRequested {
+			cancelled = append(cancelled, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cancelled, nil
+}
+
+// RequestImportJobCancel просит владельца задачи отменить её. false — задача уже завершена
+func (s *Storage) RequestImportJobCancel(ctx context.Context, id int64) (bool, error) {
+	const op = "storage.postgresql.import_jobs.RequestImportJobCancel"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		UPDATE public.import_jobs SET
+			cancel_requested = true,
+			updated_at = now()
+		WHERE id = $1 AND finished_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// FailExpiredImportJobs завершает задачи, аренду которых никто не продлевает: экземпляр,
+// выполнявший их, остановлен или упал. Задачи с запрошенной отменой помечаются отменёнными,
+// остальные — проваленными с причиной reason. Задачи в очереди аренды не имеют и ждут,
+// пока их заберут; завершаются только оставшиеся от версий, хранивших файл в памяти
+func (s *Storage) FailExpiredImportJobs(ctx context.Context, reason string) (int64, error) {
+	const op = "storage.postgresql.import_jobs.FailExpiredImportJobs"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	// Задачи без аренды остались от версий до её появления
+	query := `
+		UPDATE public.import_jobs SET
+			status = CASE WHEN cancel_requested THEN $1 ELSE $2 END,
+			error = CASE WHEN cancel_requested THEN NULL ELSE $3 END,
+			finished_at = now(),
+			updated_at = now()
+		WHERE status IN ($4, $5) AND (lease_until IS NULL OR lease_until < now())
+			AND (status = $5 OR payload_key IS NULL)
+		RETURNING payload_key`
+
+	rows, err := s.db.QueryContext(ctx, query,
+		models.ImportJobCancelled,
+		models.ImportJobFailed,
+		reason,
+		models.ImportJobQueued,
+		models.ImportJobRunning,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var failed int64
+	var payloadKeys []string
+	for rows.Next() {
+		var payloadKey sql.NullString
+		if err := rows.Scan(&payloadKey); err != nil {
+			return failed, fmt.Errorf("%s: %w", op, err)
+		}
+		failed++
+		if payloadKey.Valid {
+			payloadKeys = append(payloadKeys, payloadKey.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return failed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, key := range payloadKeys {
+		s.deletePayload(ctx, key)
+	}
+
+	return failed, nil
+}
+
+// deletePayload удаляет файл импорта. Ошибка не мешает основной операции: в худшем случае
+// файл останется лежать в хранилище
+func (s *Storage) deletePayload(ctx context.Context, key string) {
+	_ = s.blobs.Delete(ctx, key)
+}
+
+func nonNilRowErrors(errs []models.ImportRowError) []models.ImportRowError {
+	if errs == nil {
+		return []models.ImportRowError{}
+	}
+	return errs
+}
+
+func nonNilOrgUnits(units []models.OrgUnit) []models.OrgUnit {
+	if units == nil {
+		return []models.OrgUnit{}
+	}
+	return units
+}
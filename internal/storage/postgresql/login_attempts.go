@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/tracing"
+	"time"
+)
+
+// GetLoginAttempts попытки входа по ключу; если их не было — пустое значение
+func (s *Storage) GetLoginAttempts(ctx context.Context, key string) (models.LoginAttempts, error) {
+	const op = "storage.postgresql.login_attempts.GetLoginAttempts"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	attempts := models.LoginAttempts{Key: key}
+	var lockedUntil sql.NullTime
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT failures, last_failure, locked_until FROM public.login_attempts WHERE key = $1`, key,
+	).Scan(&attempts.Failures, &attempts.LastFailure, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return attempts, nil
+		}
+		return attempts, fmt.Errorf("%s: %w", op, err)
+	}
+	attempts.LockedUntil = lockedUntil.Time
+
+	return attempts, nil
+}
+
+// RecordLoginFailure увеличивает счётчик неудач; счётчик начинается заново,
+// если прошлая неудача была раньше, чем window назад
+func (s *Storage) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	const op = "storage.postgresql.login_attempts.RecordLoginFailure"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		INSERT INTO public.login_attempts (key, failures, last_failure)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure = EXCLUDED.last_failure
+		RETURNING failures`
+
+	var failures int
+	if err := s.db.QueryRowContext(ctx, query, key, now, now.Add(-window)).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+// LockLogin запрещает вход по ключу до until
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	const op = "storage.postgresql.login_attempts.LockLogin"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE public.login_attempts SET locked_until = $2 WHERE key = $1`, key, until); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetLoginAttempts забывает неудачи по ключу после успешного входа
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) error {
+	const op = "storage.postgresql.login_attempts.ResetLoginAttempts"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM public.login_attempts WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts удаляет записи без неудач после before и без действующей блокировки
+func (s *Storage) DeleteStaleLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgresql.login_attempts.DeleteStaleLoginAttempts"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM public.login_attempts WHERE last_failure < $1 AND (locked_until IS NULL OR locked_until < now())`, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n, nil
+}
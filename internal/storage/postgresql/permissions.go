@@ -0,0 +1,172 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage"
+	"telephone-book/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+const grantColumns = `id, user_id, role, institute, department, created_by, created_at`
+
+// CreateGrant выдаёт право; институт сохраняется в каноническом виде
+func (s *Storage) CreateGrant(ctx context.Context, grant models.Grant) (models.Grant, error) {
+	const op = "storage.postgresql.permissions.CreateGrant"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	institute, err := storage.Schema(grant.Institute)
+	if err != nil {
+		return grant, err
+	}
+	grant.Institute = institute
+
+	query := `
+		INSERT INTO public.permissions (user_id, role, institute, department, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	err = s.db.QueryRowContext(ctx, query,
+		grant.UserID,
+		grant.Role,
+		grant.Institute,
+		grant.Department,
+		sql.NullInt64{Int64: grant.CreatedBy, Valid: grant.CreatedBy != 0},
+	).Scan(&grant.ID, &grant.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return grant, storage.ErrGrantAlreadyExists
+		}
+		return grant, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grant, nil
+}
+
+// GetGrant возвращает право по id
+func (s *Storage) GetGrant(ctx context.Context, id int64) (models.Grant, error) {
+	const op = "storage.postgresql.permissions.GetGrant"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + grantColumns + ` FROM public.permissions WHERE id = $1`
+
+	grant, err := scanGrant(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return grant, storage.ErrGrantNotFound
+		}
+		return grant, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grant, nil
+}
+
+// DeleteGrant отзывает право
+func (s *Storage) DeleteGrant(ctx context.Context, id int64) error {
+	const op = "storage.postgresql.permissions.DeleteGrant"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM public.permissions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrGrantNotFound
+	}
+
+	return nil
+}
+
+// GetUserGrants права пользователя во всех институтах
+func (s *Storage) GetUserGrants(ctx context.Context, userID int64) ([]models.Grant, error) {
+	const op = "storage.postgresql.permissions.GetUserGrants"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + grantColumns + ` FROM public.permissions WHERE user_id = $1 ORDER BY id`
+
+	grants, err := s.queryGrants(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grants, nil
+}
+
+// GetInstituteGrants все права в институте
+func (s *Storage) GetInstituteGrants(ctx context.Context, institute string) ([]models.Grant, error) {
+	const op = "storage.postgresql.permissions.GetInstituteGrants"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	institute, err := storage.Schema(institute)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + grantColumns + ` FROM public.permissions WHERE institute = $1 ORDER BY department, user_id, id`
+
+	grants, err := s.queryGrants(ctx, query, institute)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return grants, nil
+}
+
+func (s *Storage) queryGrants(ctx context.Context, query string, args ...any) ([]models.Grant, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.Grant{}
+	for rows.Next() {
+		grant, err := scanGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return grants, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGrant(row rowScanner) (models.Grant, error) {
+	var grant models.Grant
+	var createdBy sql.NullInt64
+
+	err := row.Scan(
+		&grant.ID,
+		&grant.UserID,
+		&grant.Role,
+		&grant.Institute,
+		&grant.Department,
+		&createdBy,
+		&grant.CreatedAt,
+	)
+	grant.CreatedBy = createdBy.Int64
+
+	return grant, err
+}
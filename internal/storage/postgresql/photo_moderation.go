@@ -0,0 +1,222 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage"
+	"telephone-book/internal/tracing"
+)
+
+// SubmitPendingPhoto ставит фотографию в очередь на одобрение.
+// Текущее фото работника не меняется; предыдущая неодобренная фотография заменяется.
+func (s *Storage) SubmitPendingPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, submittedBy int64) (int, error) {
+	const op = "storage.postgresql.SubmitPendingPhoto"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	photoHash, photoKey, err := s.putPhoto(ctx, institute, photo)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `WITH worker AS (SELECT id FROM workers WHERE email = $8),
+			old AS (SELECT p.photo_filename FROM pending_photos p JOIN worker ON p.worker_id = worker.id)
+		INSERT INTO pending_photos (worker_id, photo_hash, photo_filename,
+			photo_crop_x, photo_crop_y, photo_crop_width, photo_crop_height, submitted_by)
+		SELECT worker.id, $1, $2, $3, $4, $5, $6, $7 FROM worker
+		ON CONFLICT (worker_id) DO UPDATE SET
+			photo_hash = EXCLUDED.photo_hash,
+			photo_filename = EXCLUDED.photo_filename,
+			photo_crop_x = EXCLUDED.photo_crop_x,
+			photo_crop_y = EXCLUDED.photo_crop_y,
+			photo_crop_width = EXCLUDED.photo_crop_width,
+			photo_crop_height = EXCLUDED.photo_crop_height,
+			submitted_by = EXCLUDED.submitted_by,
+			submitted_at = NOW()
+		RETURNING id, (SELECT photo_filename FROM old)`
+
+	var submitter sql.NullInt64
+	if submittedBy != 0 {
+		submitter = sql.NullInt64{Int64: submittedBy, Valid: true}
+	}
+
+	args := append([]any{photoHash, photoKey}, cropArgs(crop)...)
+	args = append(args, submitter, email)
+
+	var id int
+	var oldPhotoKey sql.NullString
+	err = s.queryRow(ctx, institute, query, args, &id, &oldPhotoKey)
+	if err != nil {
+		s.releasePhoto(ctx, photoKey)
+		if err == sql.ErrNoRows {
+			return 0, storage.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if oldPhotoKey != photoKey {
+		s.releasePhoto(ctx, oldPhotoKey)
+	}
+
+	return id, nil
+}
+
+// GetPendingPhotos возвращает очередь фотографий на одобрение, старые первыми
+func (s *Storage) GetPendingPhotos(ctx context.Context, institute string) ([]models.PendingPhoto, error) {
+	const op = "storage.postgresql.GetPendingPhotos"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := s.SetSchema(ctx, institute); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT p.id, p.worker_id, w.email, w.surname, w.name, w.middle_name,
+			p.photo_crop_x, p.photo_crop_y, p.photo_crop_width, p.photo_crop_height,
+			p.submitted_by, p.submitted_at
+		FROM pending_photos p JOIN workers w ON w.id = p.worker_id
+		ORDER BY p.submitted_at, p.id`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	photos := []models.PendingPhoto{}
+	for rows.Next() {
+		var photo models.PendingPhoto
+		var middleName sql.NullString
+		var submittedBy sql.NullInt64
+		var crop nullCrop
+
+		err := rows.Scan(
+			&photo.ID,
+			&photo.WorkerID,
+			&photo.Email,
+			&photo.Surname,
+			&photo.Name,
+			&middleName,
+			&crop.x, &crop.y, &crop.width, &crop.height,
+			&submittedBy,
+			&photo.SubmittedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		photo.MiddleName = middleName.String
+		photo.SubmittedBy = submittedBy.Int64
+		photo.Crop = crop.rect()
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return photos, nil
+}
+
+// GetPendingPhotoImage возвращает фотографию из очереди и её область кадрирования
+func (s *Storage) GetPendingPhotoImage(ctx context.Context, institute string, id int) ([]byte, *models.CropRect, error) {
+	const op = "storage.postgresql.GetPendingPhotoImage"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT photo_filename, photo_crop_x, photo_crop_y, photo_crop_width, photo_crop_height
+		FROM pending_photos WHERE id = $1`
+
+	var photoKey sql.NullString
+	var crop nullCrop
+	err := s.queryRow(ctx, institute, query, []any{id}, &photoKey, &crop.x, &crop.y, &crop.width, &crop.height)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, storage.ErrPendingPhotoNotFound
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	photo, err := s.loadPhoto(ctx, photoKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return photo, crop.rect(), nil
+}
+
+// ApprovePendingPhoto делает фотографию из очереди фотографией работника и заменяет миниатюры
+func (s *Storage) ApprovePendingPhoto(ctx context.Context, institute string, id int, thumbnails map[int][]byte) error {
+	const op = "storage.postgresql.ApprovePendingPhoto"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	thumbnailKeys, err := s.putThumbnails(ctx, institute, thumbnails)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `WITH pending AS (DELETE FROM pending_photos WHERE id = $1
+				RETURNING worker_id, photo_hash, photo_filename,
+					photo_crop_x, photo_crop_y, photo_crop_width, photo_crop_height),
+			old AS (SELECT w.id, w.photo_filename FROM workers w JOIN pending ON w.id = pending.worker_id)
+		UPDATE workers w SET photo_hash = pending.photo_hash, photo_filename = pending.photo_filename,
+			photo_crop_x = pending.photo_crop_x, photo_crop_y = pending.photo_crop_y,
+			photo_crop_width = pending.photo_crop_width, photo_crop_height = pending.photo_crop_height
+		FROM pending, old WHERE w.id = pending.worker_id AND old.id = w.id
+		RETURNING w.id, old.photo_filename`
+
+	var oldPhotoKey sql.NullString
+	var oldThumbnailKeys []string
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var workerID int
+		if err := tx.QueryRowContext(ctx, query, id).Scan(&workerID, &oldPhotoKey); err != nil {
+			return err
+		}
+
+		var err error
+		oldThumbnailKeys, err = replaceThumbnails(ctx, tx, workerID, thumbnailKeys)
+		return err
+	})
+	if err != nil {
+		s.releaseBlobs(ctx, slices.Collect(maps.Values(thumbnailKeys)))
+		if err == sql.ErrNoRows {
+			return storage.ErrPendingPhotoNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.releasePhoto(ctx, oldPhotoKey)
+	s.releaseBlobs(ctx, oldThumbnailKeys)
+
+	return nil
+}
+
+// RejectPendingPhoto удаляет фотографию из очереди; текущее фото работника остаётся
+func (s *Storage) RejectPendingPhoto(ctx context.Context, institute string, id int) error {
+	const op = "storage.postgresql.RejectPendingPhoto"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var photoKey sql.NullString
+	err := s.queryRow(ctx, institute, `DELETE FROM pending_photos WHERE id = $1 RETURNING photo_filename`, []any{id}, &photoKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrPendingPhotoNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.releasePhoto(ctx, photoKey)
+
+	return nil
+}
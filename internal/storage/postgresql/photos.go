@@ -0,0 +1,303 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/storage/blob"
+	"telephone-book/internal/tracing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// putPhoto сохраняет фотографию в BlobStore и возвращает хеш содержимого и ключ объекта.
+// Ключ зависит только от схемы и содержимого, поэтому одинаковые фото хранятся один раз.
+func (s *Storage) putPhoto(ctx context.Context, institute string, photo []byte) (sql.NullString, sql.NullString, error) {
+	if len(photo) == 0 {
+		return sql.NullString{}, sql.NullString{}, nil
+	}
+
+	hash, key, err := s.putBlob(ctx, institute, "photos", photo)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, fmt.Errorf("failed to store photo: %w", err)
+	}
+
+	return sql.NullString{String: hash, Valid: true}, sql.NullString{String: key, Valid: true}, nil
+}
+
+// putThumbnails сохраняет миниатюры в BlobStore так же, как putPhoto, и возвращает ключи
+// объектов по размеру. При ошибке уже записанные объекты освобождаются
+func (s *Storage) putThumbnails(ctx context.Context, institute string, thumbnails map[int][]byte) (map[int]string, error) {
+	keys := make(map[int]string, len(thumbnails))
+	for size, data := range thumbnails {
+		_, key, err := s.putBlob(ctx, institute, "thumbnails", data)
+		if err != nil {
+			s.releaseBlobs(ctx, slices.Collect(maps.Values(keys)))
+			return nil, fmt.Errorf("failed to store %dpx thumbnail: %w", size, err)
+		}
+		keys[size] = key
+	}
+
+	return keys, nil
+}
+
+// putBlob записывает объект под ключом <схема>/<kind>/<хеш>.
+// Ключ убирается из списка сирот до записи: если его как раз удаляет DeleteOrphanPhotos,
+// удаление блокирует строку списка, и запись начнётся только после него.
+func (s *Storage) putBlob(ctx context.Context, institute string, kind string, data []byte) (string, string, error) {
+	schema, err := schemaName(institute)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := fmt.Sprintf("%s/%s/%s/%s", schema, kind, hash[:2], hash)
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM public.photo_orphans WHERE key = $1`, key); err != nil {
+		return "", "", fmt.Errorf("failed to claim blob: %w", err)
+	}
+
+	if err := s.blobs.Put(ctx, key, data, http.DetectContentType(data)); err != nil {
+		return "", "", err
+	}
+
+	return hash, key, nil
+}
+
+// loadPhoto читает фотографию из BlobStore; работник без фото — nil
+func (s *Storage) loadPhoto(ctx context.Context, key sql.NullString) ([]byte, error) {
+	if !key.Valid {
+		return nil, nil
+	}
+
+	photo, err := s.blobs.Get(ctx, key.String)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, fmt.Errorf("photo %s is missing in blob store: %w", key.String, err)
+		}
+		return nil, fmt.Errorf("failed to load photo: %w", err)
+	}
+
+	return photo, nil
+}
+
+// releasePhoto отмечает объект, на который, возможно, больше никто не ссылается. Сразу объект
+// не удаляется: между записью объекта и сохранением строки со ссылкой на него проходит время,
+// и проверка ссылок здесь могла бы удалить фото, которое как раз сохраняется. Удаляет
+// DeleteOrphanPhotos после выдержки. Ошибки не критичны: остаётся лишь лишний объект.
+func (s *Storage) releasePhoto(ctx context.Context, key sql.NullString) {
+	if !key.Valid {
+		return
+	}
+
+	query := `INSERT INTO public.photo_orphans (key) VALUES ($1)
+		ON CONFLICT (key) DO UPDATE SET released_at = now()`
+	_, _ = s.db.ExecContext(ctx, query, key.String)
+}
+
+// releaseBlobs освобождает объекты миниатюр, см. releasePhoto
+func (s *Storage) releaseBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		s.releasePhoto(ctx, sql.NullString{String: key, Valid: true})
+	}
+}
+
+// DeleteOrphanPhotos удаляет из BlobStore объекты, отмеченные releasePhoto раньше чем grace назад,
+// если на них не ссылаются ни работники, ни очередь модерации, ни миниатюры схемы из ключа.
+// Отметки читаются пачками по batchSize; возвращает число удалённых объектов
+func (s *Storage) DeleteOrphanPhotos(ctx context.Context, grace time.Duration, batchSize int) (int, error) {
+	const op = "storage.postgresql.photos.DeleteOrphanPhotos"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT key FROM public.photo_orphans
+		WHERE released_at < now() - make_interval(secs => $1)
+		ORDER BY released_at LIMIT $2`
+
+	deleted := 0
+	for {
+		rows, err := s.db.QueryContext(ctx, query, grace.Seconds(), batchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var keys []string
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return deleted, fmt.Errorf("%s: failed to scan row: %w", op, err)
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return deleted, fmt.Errorf("%s: rows error: %w", op, err)
+		}
+
+		// Обработанные отметки удаляются, поэтому следующая пачка — уже другие ключи
+		for _, key := range keys {
+			ok, err := s.deleteOrphanPhoto(ctx, key, grace)
+			if err != nil {
+				return deleted, fmt.Errorf("%s: %s: %w", op, key, err)
+			}
+			if ok {
+				deleted++
+			}
+		}
+
+		if len(keys) < batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteOrphanPhoto проверяет ссылки и удаляет объект, держа блокировку строки списка сирот,
+// поэтому putPhoto с тем же ключом ждёт окончания удаления
+func (s *Storage) deleteOrphanPhoto(ctx context.Context, key string, grace time.Duration) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var locked string
+	query := `SELECT key FROM public.photo_orphans
+		WHERE key = $1 AND released_at < now() - make_interval(secs => $2)
+		FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, key, grace.Seconds()).Scan(&locked)
+	if err == sql.ErrNoRows {
+		// На объект снова сослались или его отметили заново
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Ключ без известной схемы putPhoto не создаёт; такую отметку просто убираем
+	referenced := true
+	if schema, err := schemaName(strings.SplitN(key, "/", 2)[0]); err == nil {
+		query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %[1]s.workers WHERE photo_filename = $1)
+			OR EXISTS (SELECT 1 FROM %[1]s.pending_photos WHERE photo_filename = $1)
+			OR EXISTS (SELECT 1 FROM %[1]s.photo_thumbnails WHERE key = $1)`, pq.QuoteIdentifier(schema))
+		if err := tx.QueryRowContext(ctx, query, key).Scan(&referenced); err != nil {
+			return false, err
+		}
+	}
+
+	if !referenced {
+		if err := s.blobs.Delete(ctx, key); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return false, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM public.photo_orphans WHERE key = $1`, key); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return !referenced, nil
+}
+
+// MovePhotosToBlobStore переносит фотографии из колонки workers.photo в BlobStore.
+// Работает пачками и может быть прерван: перенесённые строки повторно не обрабатываются.
+// Запускается до миграции 000020, которая удаляет колонку; сервис её уже не читает.
+func (s *Storage) MovePhotosToBlobStore(ctx context.Context, institute string, batchSize int) (int, error) {
+	const op = "storage.postgresql.photos.MovePhotosToBlobStore"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	type legacyPhoto struct {
+		id    int
+		photo []byte
+	}
+
+	// Каждая пачка и каждое обновление идут в своей транзакции со схемой института:
+	// пул общий, и SET search_path на соединении мог бы увести запросы в чужую схему
+	moved := 0
+	for {
+		var batch []legacyPhoto
+		err := s.withSchema(ctx, institute, true, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, `SELECT id, photo FROM workers WHERE photo IS NOT NULL ORDER BY id LIMIT $1`, batchSize)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var p legacyPhoto
+				if err := rows.Scan(&p.id, &p.photo); err != nil {
+					return fmt.Errorf("failed to scan row: %w", err)
+				}
+				batch = append(batch, p)
+			}
+
+			return rows.Err()
+		})
+		if err != nil {
+			return moved, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if len(batch) == 0 {
+			return moved, nil
+		}
+
+		for _, p := range batch {
+			hash, key, err := s.putPhoto(ctx, institute, p.photo)
+			if err != nil {
+				return moved, fmt.Errorf("%s: worker %d: %w", op, p.id, err)
+			}
+
+			query := `UPDATE workers SET photo = NULL, photo_hash = $1, photo_filename = $2 WHERE id = $3`
+			err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, query, hash, key, p.id)
+				return err
+			})
+			if err != nil {
+				return moved, fmt.Errorf("%s: worker %d: %w", op, p.id, err)
+			}
+
+			moved++
+		}
+	}
+}
+
+// nullCrop колонки photo_crop_* при чтении
+type nullCrop struct {
+	x, y, width, height sql.NullInt64
+}
+
+func (c nullCrop) rect() *models.CropRect {
+	if !c.x.Valid || !c.y.Valid || !c.width.Valid || !c.height.Valid {
+		return nil
+	}
+	return &models.CropRect{
+		X:      int(c.x.Int64),
+		Y:      int(c.y.Int64),
+		Width:  int(c.width.Int64),
+		Height: int(c.height.Int64),
+	}
+}
+
+// cropArgs значения photo_crop_* для INSERT/UPDATE; nil сбрасывает кадрирование
+func cropArgs(crop *models.CropRect) []any {
+	if crop == nil {
+		return []any{nil, nil, nil, nil}
+	}
+	return []any{crop.X, crop.Y, crop.Width, crop.Height}
+}
@@ -7,19 +7,22 @@ import (
 	"strings"
 	"telephone-book/internal/domain/models"
 	"telephone-book/internal/storage"
+	"telephone-book/internal/storage/blob"
+	"telephone-book/internal/tracing"
 	"time"
 
 	"github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sql.DB
+	db    *sql.DB
+	blobs blob.BlobStore
 }
 
 var emptyID = 0
 
 // New creates a new Storage instance
-func New(storagePath string) (*Storage, error) {
+func New(storagePath string, blobs blob.BlobStore) (*Storage, error) {
 	const op = "storage.postgresql.New"
 
 	db, err := sql.Open("postgres", storagePath)
@@ -28,29 +31,95 @@ func New(storagePath string) (*Storage, error) {
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, blobs: blobs}, nil
+}
+
+// Ping проверяет соединение с БД (для /readyz)
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.postgresql.Ping"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Stats состояние пула соединений с БД (для /metrics)
+func (s *Storage) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Close закрывает пул соединений с БД
+func (s *Storage) Close() error {
+	return s.db.Close()
 }
 
 func (s *Storage) SetSchema(ctx context.Context, institute string) error {
-	var schema string
-	switch institute {
-	case "grafit", "графит", "Графит", "Grafit":
-		schema = "grafit"
-	case "giredmet", "Giredmet", "гиредмет", "Гиредмет":
-		schema = "giredmet"
-	default:
-		return storage.ErrSchemaNotExist
+	schema, err := schemaName(institute)
+	if err != nil {
+		return err
 	}
-	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`SET search_path TO %s`, pq.QuoteIdentifier(schema)))
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`SET search_path TO %s`, pq.QuoteIdentifier(schema)))
 	return err
 }
 
+// setTxSchema выбирает схему института для транзакции. SET LOCAL выполняется на соединении
+// транзакции и действует до её конца, тогда как SetSchema попадает на любое соединение пула
+func setTxSchema(ctx context.Context, tx *sql.Tx, institute string) error {
+	schema, err := schemaName(institute)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`SET LOCAL search_path TO %s`, pq.QuoteIdentifier(schema)))
+	return err
+}
+
+// withSchema выполняет fn в транзакции со схемой института. Запросы fn идут через tx и
+// поэтому видят именно эту схему, даже если параллельно обслуживается другой институт.
+// readOnly открывает транзакцию только для чтения
+func (s *Storage) withSchema(ctx context.Context, institute string, readOnly bool, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setTxSchema(ctx, tx, institute); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryRow выполняет запрос одной строки в схеме института (см. withSchema) и читает её в dest
+func (s *Storage) queryRow(ctx context.Context, institute string, query string, args []any, dest ...any) error {
+	return s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, query, args...).Scan(dest...)
+	})
+}
+
+// schemaName схема БД института
+func schemaName(institute string) (string, error) {
+	return storage.Schema(institute)
+}
+
 func (s *Storage) Search(ctx context.Context, institute string, department string, section string, info string) ([]models.User, error) {
 	const op = "storage.postgresql.Search"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	if err := s.SetSchema(ctx, institute); err != nil {
 		return nil, err
 	}
@@ -154,6 +223,7 @@ func (s *Storage) CreateUserTx(
 	name string,
 	middleName string,
 	email string,
+	personnelNumber string,
 	phoneNumber string,
 	cabinet string,
 	position string,
@@ -165,10 +235,19 @@ func (s *Storage) CreateUserTx(
 ) (int, error) {
 	const op = "storage.postgresql.CreateUser"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := setTxSchema(ctx, tx, institute); err != nil {
 		return emptyID, err
 	}
 
+	// Объект пишется вне транзакции; при откате останется лишь неиспользуемый объект
+	photoHash, photoKey, err := s.putPhoto(ctx, institute, photo)
+	if err != nil {
+		return emptyID, fmt.Errorf("%s: %w", op, err)
+	}
+
 	var id int
 
 	query := `
@@ -176,13 +255,14 @@ func (s *Storage) CreateUserTx(
 		surname, name, middle_name,
 		email, phone_number, cabinet,
 		position, department, section,
-		birth_date, description, photo
+		birth_date, description, photo_hash,
+		personnel_number, photo_filename
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id
 		`
 
-	err := tx.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		surname,
@@ -196,7 +276,9 @@ func (s *Storage) CreateUserTx(
 		section,
 		birthDate,
 		description,
-		photo,
+		photoHash,
+		sql.NullString{String: personnelNumber, Valid: personnelNumber != ""},
+		photoKey,
 	).Scan(&id)
 
 	if err != nil {
@@ -212,6 +294,9 @@ func (s *Storage) CreateUserTx(
 func (s *Storage) ImportUsers(ctx context.Context, institute string, users []models.User) error {
 	const op = "storage.postgresql.ImportUsers"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	if err := s.SetSchema(ctx, institute); err != nil {
 		return err
 	}
@@ -235,6 +320,7 @@ func (s *Storage) ImportUsers(ctx context.Context, institute string, users []mod
 			user.Name,
 			user.MiddleName,
 			user.Email,
+			user.PersonnelNumber,
 			user.PhoneNumber,
 			user.Cabinet,
 			user.Position,
@@ -242,7 +328,7 @@ func (s *Storage) ImportUsers(ctx context.Context, institute string, users []mod
 			user.Section,
 			user.BirthDate,
 			user.Description,
-			nil,
+			user.Photo,
 		)
 		if err != nil {
 			return fmt.Errorf("%s: failed to create user %s: %w", op, user.Email, err)
@@ -255,6 +341,9 @@ func (s *Storage) ImportUsers(ctx context.Context, institute string, users []mod
 func (s *Storage) Emergency(ctx context.Context) ([]models.Service, error) {
 	const op = "storage.postgesql.Emergency"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	rows, err := s.db.QueryContext(ctx, `SELECT name, phone_number, email FROM public.main`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -295,6 +384,9 @@ func (s *Storage) GetTomorrowsBirthdays(ctx context.Context, institute string) (
 func (s *Storage) getBirthdaysByOffset(ctx context.Context, institute string, dayOffset int) ([]models.User, error) {
 	const op = "storage.postgresql.getBirthdaysByOffset"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	var schema string
 	switch institute {
 	case "grafit", "графит", "Графит", "Grafit":
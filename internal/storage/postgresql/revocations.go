@@ -0,0 +1,128 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telephone-book/internal/domain/models"
+	"telephone-book/internal/tracing"
+	"time"
+)
+
+// RevokeToken сохраняет отозванный токен до истечения его срока
+func (s *Storage) RevokeToken(ctx context.Context, token models.RevokedToken) error {
+	const op = "storage.postgresql.revocations.RevokeToken"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		INSERT INTO public.revoked_tokens (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token_hash) DO NOTHING`
+
+	if _, err := s.db.ExecContext(ctx, query, token.Hash, token.UserID, token.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзывает все токены пользователя, выданные до session.RevokedBefore
+func (s *Storage) RevokeUserSessions(ctx context.Context, session models.RevokedSession, revokedBy int64) error {
+	const op = "storage.postgresql.revocations.RevokeUserSessions"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `
+		INSERT INTO public.revoked_sessions (user_id, revoked_before, revoked_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			revoked_before = GREATEST(revoked_sessions.revoked_before, EXCLUDED.revoked_before),
+			revoked_by = EXCLUDED.revoked_by`
+
+	_, err := s.db.ExecContext(ctx, query,
+		session.UserID,
+		session.RevokedBefore,
+		sql.NullInt64{Int64: revokedBy, Valid: revokedBy != 0},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetRevocations возвращает все ещё действующие отзывы
+func (s *Storage) GetRevocations(ctx context.Context) ([]models.RevokedToken, []models.RevokedSession, error) {
+	const op = "storage.postgresql.revocations.GetRevocations"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT token_hash, user_id, expires_at FROM public.revoked_tokens WHERE expires_at > now()`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tokens []models.RevokedToken
+	for rows.Next() {
+		var token models.RevokedToken
+		if err := rows.Scan(&token.Hash, &token.UserID, &token.ExpiresAt); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to scan token: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	sessionRows, err := s.db.QueryContext(ctx, `SELECT user_id, revoked_before FROM public.revoked_sessions`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer sessionRows.Close()
+
+	var sessions []models.RevokedSession
+	for sessionRows.Next() {
+		var session models.RevokedSession
+		if err := sessionRows.Scan(&session.UserID, &session.RevokedBefore); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to scan session: %w", op, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := sessionRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return tokens, sessions, nil
+}
+
+// DeleteExpiredRevocations удаляет отзывы, под которые уже не может попасть ни один живой токен.
+// maxTokenAge — наибольшее время жизни токена с учётом расхождения часов.
+func (s *Storage) DeleteExpiredRevocations(ctx context.Context, maxTokenAge time.Duration) (int64, error) {
+	const op = "storage.postgresql.revocations.DeleteExpiredRevocations"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tokens, err := s.db.ExecContext(ctx, `DELETE FROM public.revoked_tokens WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions, err := s.db.ExecContext(ctx,
+		`DELETE FROM public.revoked_sessions WHERE revoked_before < now() - make_interval(secs => $1)`,
+		maxTokenAge.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deletedTokens, _ := tokens.RowsAffected()
+	deletedSessions, _ := sessions.RowsAffected()
+
+	return deletedTokens + deletedSessions, nil
+}
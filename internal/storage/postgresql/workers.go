@@ -3,9 +3,14 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"telephone-book/internal/domain/models"
 	"telephone-book/internal/storage"
+	"telephone-book/internal/storage/blob"
+	"telephone-book/internal/tracing"
 	"time"
 
 	"github.com/lib/pq"
@@ -18,6 +23,7 @@ func (s *Storage) CreateUser(
 	name string,
 	middleName string,
 	email string,
+	personnelNumber string,
 	phoneNumber string,
 	cabinet string,
 	position string,
@@ -29,10 +35,19 @@ func (s *Storage) CreateUser(
 ) (int, error) {
 	const op = "storage.postgresql.CreateUser"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	// Схема до записи объекта: неизвестный институт не должен оставлять объектов
+	if _, err := schemaName(institute); err != nil {
 		return emptyID, err
 	}
 
+	photoHash, photoKey, err := s.putPhoto(ctx, institute, photo)
+	if err != nil {
+		return emptyID, fmt.Errorf("%s: %w", op, err)
+	}
+
 	var id int
 
 	query := `
@@ -40,30 +55,37 @@ func (s *Storage) CreateUser(
 			surname, name, middle_name,
 			email, phone_number, cabinet,
 			position, department, section,
-			birth_date, description, photo
+			birth_date, description, photo_hash,
+			personnel_number, photo_filename
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) 
 		RETURNING id
 		`
 
-	err := s.db.QueryRowContext(
-		ctx,
-		query,
-		surname,
-		name,
-		middleName,
-		email,
-		phoneNumber,
-		cabinet,
-		position,
-		department,
-		section,
-		birthDate,
-		description,
-		photo,
-	).Scan(&id)
+	// Работники создаются и фоновым импортом, параллельно с запросами к другому институту
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(
+			ctx,
+			query,
+			surname,
+			name,
+			middleName,
+			email,
+			phoneNumber,
+			cabinet,
+			position,
+			department,
+			section,
+			birthDate,
+			description,
+			photoHash,
+			sql.NullString{String: personnelNumber, Valid: personnelNumber != ""},
+			photoKey,
+		).Scan(&id)
+	})
 
 	if err != nil {
+		s.releasePhoto(ctx, photoKey)
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			return emptyID, storage.ErrUserAlreadyExists
 		}
@@ -80,25 +102,28 @@ func (s *Storage) DeleteUser(
 ) error {
 	const op = "storage.postgresql.DeleteUser"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	query := "DELETE FROM workers WHERE email = $1"
+	// Неодобренная фотография и миниатюры удаляются каскадно, их объекты тоже нужно освободить.
+	// Подзапросы в RETURNING видят строки до удаления
+	query := `DELETE FROM workers WHERE email = $1
+		RETURNING photo_filename, (SELECT photo_filename FROM pending_photos WHERE worker_id = workers.id),
+			ARRAY(SELECT key FROM photo_thumbnails WHERE worker_id = workers.id)`
 
-	result, err := s.db.ExecContext(ctx, query, email)
+	var photoKey, pendingPhotoKey sql.NullString
+	var thumbnailKeys pq.StringArray
+	err := s.queryRow(ctx, institute, query, []any{email}, &photoKey, &pendingPhotoKey, &thumbnailKeys)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrUserNotFound
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
-	}
-
-	if rowsAffected == 0 {
-		return storage.ErrUserNotFound
-	}
+	s.releasePhoto(ctx, photoKey)
+	s.releasePhoto(ctx, pendingPhotoKey)
+	s.releaseBlobs(ctx, thumbnailKeys)
 
 	return nil
 }
@@ -122,11 +147,65 @@ func (s *Storage) UpdateUser(
 ) error {
 	const op = "storage.postgresql.UpdateUser"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return err
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	// Без новой фотографии прежняя вместе с кадрированием и миниатюрами остаётся как есть
+	if len(photo) == 0 {
+		var affected int64
+		err := s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, `UPDATE workers SET
+					surname = $1,
+					name = $2,
+					middle_name = $3,
+					email = $4,
+					phone_number = $5,
+					cabinet = $6,
+					position = $7,
+					department = $8,
+					section = $9,
+					birth_date = $10,
+					description = $11
+				WHERE email = $12`,
+				surname,
+				name,
+				middleName,
+				email,
+				phoneNumber,
+				cabinet,
+				position,
+				department,
+				section,
+				birthDate,
+				description,
+				oldEmail,
+			)
+			if err != nil {
+				return err
+			}
+			affected, err = res.RowsAffected()
+			return err
+		})
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return storage.ErrUserAlreadyExists
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if affected == 0 {
+			return storage.ErrUserNotFound
+		}
+		return nil
+	}
+
+	photoHash, photoKey, err := s.putPhoto(ctx, institute, photo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	query := `UPDATE workers SET 
+	// old нужен, чтобы после обновления освободить прежнюю фотографию
+	query := `WITH old AS (SELECT id, photo_filename FROM workers WHERE email = $14)
+		UPDATE workers w SET 
 			surname = $1, 
 			name = $2, 
 			middle_name = $3, 
@@ -138,40 +217,59 @@ func (s *Storage) UpdateUser(
 			section = $9,
 			birth_date = $10,
 			description = $11,
-			photo = $12
-		WHERE email = $13`
-
-	result, err := s.db.ExecContext(
-		ctx,
-		query,
-		surname,
-		name,
-		middleName,
-		email,
-		phoneNumber,
-		cabinet,
-		position,
-		department,
-		section,
-		birthDate,
-		description,
-		photo,
-		oldEmail,
-	)
+			photo_hash = $12,
+			photo_filename = $13,
+			photo_crop_x = NULL,
+			photo_crop_y = NULL,
+			photo_crop_width = NULL,
+			photo_crop_height = NULL
+		FROM old WHERE w.id = old.id
+		RETURNING w.id, old.photo_filename`
+
+	// Миниатюры прежней фотографии удаляются вместе с её заменой
+	var oldPhotoKey sql.NullString
+	var oldThumbnailKeys []string
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var id int
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			surname,
+			name,
+			middleName,
+			email,
+			phoneNumber,
+			cabinet,
+			position,
+			department,
+			section,
+			birthDate,
+			description,
+			photoHash,
+			photoKey,
+			oldEmail,
+		).Scan(&id, &oldPhotoKey)
+		if err != nil || oldPhotoKey == photoKey {
+			return err
+		}
+
+		oldThumbnailKeys, err = deleteThumbnails(ctx, tx, id)
+		return err
+	})
 	if err != nil {
+		s.releasePhoto(ctx, photoKey)
+		if err == sql.ErrNoRows {
+			return storage.ErrUserNotFound
+		}
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			return storage.ErrUserAlreadyExists
 		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
-	}
-
-	if rowsAffected == 0 {
-		return storage.ErrUserNotFound
+	if oldPhotoKey != photoKey {
+		s.releasePhoto(ctx, oldPhotoKey)
+		s.releaseBlobs(ctx, oldThumbnailKeys)
 	}
 
 	return nil
@@ -180,22 +278,24 @@ func (s *Storage) UpdateUser(
 func (s *Storage) GetUserByEmail(ctx context.Context, institute string, email string) (models.User, error) {
 	const op = "storage.postgresql.GetUserByEmail"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return models.EmptyUser, err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	query := `SELECT id, surname, name, middle_name, email, phone_number, cabinet, position, department, section, birth_date, description
+	query := `SELECT id, surname, name, middle_name, email, personnel_number, phone_number, cabinet, position, department, section, birth_date, description,
+			photo_crop_x, photo_crop_y, photo_crop_width, photo_crop_height
 		FROM workers WHERE email = $1`
 
 	var user models.User
-	var middleName, cabinet, position, department, section, description sql.NullString
+	var middleName, personnelNumber, cabinet, position, department, section, description sql.NullString
+	var crop nullCrop
 
-	err := s.db.QueryRowContext(ctx, query, email).Scan(
+	err := s.queryRow(ctx, institute, query, []any{email},
 		&user.ID,
 		&user.Surname,
 		&user.Name,
 		&middleName,
 		&user.Email,
+		&personnelNumber,
 		&user.PhoneNumber,
 		&cabinet,
 		&position,
@@ -203,6 +303,66 @@ func (s *Storage) GetUserByEmail(ctx context.Context, institute string, email st
 		&section,
 		&user.BirthDate,
 		&description,
+		&crop.x,
+		&crop.y,
+		&crop.width,
+		&crop.height,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.EmptyUser, storage.ErrUserNotFound
+		}
+		return models.EmptyUser, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Конвертируем NullString в обычные строки
+	user.MiddleName = middleName.String
+	user.PersonnelNumber = personnelNumber.String
+	user.Cabinet = cabinet.String
+	user.Position = position.String
+	user.Department = department.String
+	user.Section = section.String
+	user.Description = description.String
+	user.PhotoCrop = crop.rect()
+
+	return user, nil
+}
+
+// GetUserByID получает пользователя по идентификатору
+func (s *Storage) GetUserByID(ctx context.Context, institute string, id int) (models.User, error) {
+	const op = "storage.postgresql.GetUserByID"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT id, surname, name, middle_name, email, personnel_number, phone_number, cabinet, position, department, section, birth_date, description,
+			photo_crop_x, photo_crop_y, photo_crop_width, photo_crop_height
+		FROM workers WHERE id = $1`
+
+	var user models.User
+	var middleName, personnelNumber, cabinet, position, department, section, description sql.NullString
+	var crop nullCrop
+	var birthDate sql.NullTime
+
+	err := s.queryRow(ctx, institute, query, []any{id},
+		&user.ID,
+		&user.Surname,
+		&user.Name,
+		&middleName,
+		&user.Email,
+		&personnelNumber,
+		&user.PhoneNumber,
+		&cabinet,
+		&position,
+		&department,
+		&section,
+		&birthDate,
+		&description,
+		&crop.x,
+		&crop.y,
+		&crop.width,
+		&crop.height,
 	)
 
 	if err != nil {
@@ -214,11 +374,14 @@ func (s *Storage) GetUserByEmail(ctx context.Context, institute string, email st
 
 	// Конвертируем NullString в обычные строки
 	user.MiddleName = middleName.String
+	user.PersonnelNumber = personnelNumber.String
 	user.Cabinet = cabinet.String
 	user.Position = position.String
 	user.Department = department.String
 	user.Section = section.String
 	user.Description = description.String
+	user.BirthDate = birthDate.Time
+	user.PhotoCrop = crop.rect()
 
 	return user, nil
 }
@@ -226,6 +389,9 @@ func (s *Storage) GetUserByEmail(ctx context.Context, institute string, email st
 func (s *Storage) GetAllUsers(ctx context.Context, institute string, department string, section string) ([]models.User, error) {
 	const op = "storage.postgresql.GetAllUsers"
 
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
 	if err := s.SetSchema(ctx, institute); err != nil {
 		return nil, err
 	}
@@ -293,18 +459,38 @@ func (s *Storage) GetAllUsers(ctx context.Context, institute string, department
 	return users, nil
 }
 
+// GetUserEmailByPersonnelNumber находит email работника по табельному номеру
+func (s *Storage) GetUserEmailByPersonnelNumber(ctx context.Context, institute string, personnelNumber string) (string, error) {
+	const op = "storage.postgresql.GetUserEmailByPersonnelNumber"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT email FROM workers WHERE personnel_number = $1`
+
+	var email string
+	err := s.queryRow(ctx, institute, query, []any{personnelNumber}, &email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", storage.ErrUserNotFound
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return email, nil
+}
+
 // GetUserPhoto получает только фотографию пользователя
 func (s *Storage) GetUserPhoto(ctx context.Context, institute string, email string) ([]byte, error) {
 	const op = "storage.postgresql.GetUserPhoto"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return nil, err
-	}
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	query := `SELECT photo FROM workers WHERE email = $1`
+	query := `SELECT photo_filename FROM workers WHERE email = $1`
 
-	var photo []byte
-	err := s.db.QueryRowContext(ctx, query, email).Scan(&photo)
+	var photoKey sql.NullString
+	err := s.queryRow(ctx, institute, query, []any{email}, &photoKey)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, storage.ErrUserNotFound
@@ -312,33 +498,169 @@ func (s *Storage) GetUserPhoto(ctx context.Context, institute string, email stri
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	photo, err := s.loadPhoto(ctx, photoKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return photo, nil
 }
 
-// UpdateUserPhoto обновляет фотографию пользователя
-func (s *Storage) UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte) error {
+// GetUserPhotos получает фотографии нескольких работников одним запросом, для выгрузок.
+// Работники без фото в результат не попадают; одинаковые фото читаются из BlobStore один раз
+func (s *Storage) GetUserPhotos(ctx context.Context, institute string, emails []string) (map[string][]byte, error) {
+	const op = "storage.postgresql.GetUserPhotos"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT email, photo_filename FROM workers
+		WHERE email = ANY($1) AND photo_filename IS NOT NULL`
+
+	// Ключи читаются в транзакции, сами фото — после неё, чтобы загрузка из BlobStore не держала соединение с БД
+	type storedPhoto struct {
+		email string
+		key   sql.NullString
+	}
+
+	var stored []storedPhoto
+	err := s.withSchema(ctx, institute, true, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, pq.Array(emails))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var p storedPhoto
+			if err := rows.Scan(&p.email, &p.key); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			stored = append(stored, p)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	photos := make(map[string][]byte, len(stored))
+	byKey := make(map[string][]byte)
+	for _, p := range stored {
+		if cached, ok := byKey[p.key.String]; p.key.Valid && ok {
+			photos[p.email] = cached
+			continue
+		}
+
+		photo, err := s.loadPhoto(ctx, p.key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if p.key.Valid {
+			byKey[p.key.String] = photo
+		}
+		photos[p.email] = photo
+	}
+
+	return photos, nil
+}
+
+// UpdateUserPhoto обновляет фотографию и область кадрирования пользователя и заменяет миниатюры
+func (s *Storage) UpdateUserPhoto(ctx context.Context, institute string, email string, photo []byte, crop *models.CropRect, thumbnails map[int][]byte) error {
 	const op = "storage.postgresql.UpdateUserPhoto"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
-		return err
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	photoHash, photoKey, err := s.putPhoto(ctx, institute, photo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	thumbnailKeys, err := s.putThumbnails(ctx, institute, thumbnails)
+	if err != nil {
+		s.releasePhoto(ctx, photoKey)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	query := `UPDATE workers SET photo = $1 WHERE email = $2`
+	query := `WITH old AS (SELECT id, photo_filename FROM workers WHERE email = $7)
+		UPDATE workers w SET photo_hash = $1, photo_filename = $2,
+			photo_crop_x = $3, photo_crop_y = $4, photo_crop_width = $5, photo_crop_height = $6
+		FROM old WHERE w.id = old.id
+		RETURNING w.id, old.photo_filename`
+
+	args := append([]any{photoHash, photoKey}, cropArgs(crop)...)
+	args = append(args, email)
+
+	var oldPhotoKey sql.NullString
+	var oldThumbnailKeys []string
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var id int
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&id, &oldPhotoKey); err != nil {
+			return err
+		}
 
-	result, err := s.db.ExecContext(ctx, query, photo, email)
+		var err error
+		oldThumbnailKeys, err = replaceThumbnails(ctx, tx, id, thumbnailKeys)
+		return err
+	})
 	if err != nil {
+		s.releasePhoto(ctx, photoKey)
+		s.releaseBlobs(ctx, slices.Collect(maps.Values(thumbnailKeys)))
+		if err == sql.ErrNoRows {
+			return storage.ErrUserNotFound
+		}
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if oldPhotoKey != photoKey {
+		s.releasePhoto(ctx, oldPhotoKey)
+	}
+	s.releaseBlobs(ctx, oldThumbnailKeys)
+
+	return nil
+}
+
+// UpdateUserPhotoCrop меняет область кадрирования без замены оригинала и заменяет миниатюры
+func (s *Storage) UpdateUserPhotoCrop(ctx context.Context, institute string, email string, crop *models.CropRect, thumbnails map[int][]byte) error {
+	const op = "storage.postgresql.UpdateUserPhotoCrop"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	thumbnailKeys, err := s.putThumbnails(ctx, institute, thumbnails)
 	if err != nil {
-		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return storage.ErrUserNotFound
+	query := `UPDATE workers SET photo_crop_x = $1, photo_crop_y = $2, photo_crop_width = $3, photo_crop_height = $4
+		WHERE email = $5
+		RETURNING id`
+
+	args := append(cropArgs(crop), email)
+
+	var oldThumbnailKeys []string
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var id int
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			return err
+		}
+
+		var err error
+		oldThumbnailKeys, err = replaceThumbnails(ctx, tx, id, thumbnailKeys)
+		return err
+	})
+	if err != nil {
+		s.releaseBlobs(ctx, slices.Collect(maps.Values(thumbnailKeys)))
+		if err == sql.ErrNoRows {
+			return storage.ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.releaseBlobs(ctx, oldThumbnailKeys)
+
 	return nil
 }
 
@@ -346,25 +668,187 @@ func (s *Storage) UpdateUserPhoto(ctx context.Context, institute string, email s
 func (s *Storage) DeleteUserPhoto(ctx context.Context, institute string, email string) error {
 	const op = "storage.postgresql.DeleteUserPhoto"
 
-	if err := s.SetSchema(ctx, institute); err != nil {
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `WITH old AS (SELECT id, photo_filename FROM workers WHERE email = $1)
+		UPDATE workers w SET photo_hash = NULL, photo_filename = NULL,
+			photo_crop_x = NULL, photo_crop_y = NULL, photo_crop_width = NULL, photo_crop_height = NULL
+		FROM old WHERE w.id = old.id
+		RETURNING w.id, old.photo_filename`
+
+	// Фото и миниатюры удаляются вместе: иначе при сбое остались бы миниатюры удалённого фото
+	var oldPhotoKey sql.NullString
+	var oldThumbnailKeys []string
+	err := s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var id int
+		if err := tx.QueryRowContext(ctx, query, email).Scan(&id, &oldPhotoKey); err != nil {
+			return err
+		}
+
+		var err error
+		oldThumbnailKeys, err = deleteThumbnails(ctx, tx, id)
 		return err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrUserNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	query := `UPDATE workers SET photo = NULL WHERE email = $1`
+	s.releasePhoto(ctx, oldPhotoKey)
+	s.releaseBlobs(ctx, oldThumbnailKeys)
+
+	return nil
+}
 
-	result, err := s.db.ExecContext(ctx, query, email)
+// GetUserPhotoByID получает оригинал фотографии пользователя по id
+func (s *Storage) GetUserPhotoByID(ctx context.Context, institute string, id int) ([]byte, error) {
+	const op = "storage.postgresql.GetUserPhotoByID"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT photo_filename FROM workers WHERE id = $1`
+
+	var photoKey sql.NullString
+	err := s.queryRow(ctx, institute, query, []any{id}, &photoKey)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	photo, err := s.loadPhoto(ctx, photoKey)
 	if err != nil {
-		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return storage.ErrUserNotFound
+	return photo, nil
+}
+
+// GetUserPhotoThumbnail получает миниатюру фотографии заданного размера. Миниатюры без
+// объекта в BlobStore считаются отсутствующими: обработчик построит их заново
+func (s *Storage) GetUserPhotoThumbnail(ctx context.Context, institute string, id int, size int) ([]byte, error) {
+	const op = "storage.postgresql.GetUserPhotoThumbnail"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT key FROM photo_thumbnails WHERE worker_id = $1 AND size = $2`
+
+	var key string
+	err := s.queryRow(ctx, institute, query, []any{id, size}, &key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrThumbnailNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, storage.ErrThumbnailNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	return data, nil
+}
+
+// SaveUserPhotoThumbnails сохраняет миниатюры, заменяя существующие тех же размеров
+func (s *Storage) SaveUserPhotoThumbnails(ctx context.Context, institute string, id int, thumbnails map[int][]byte) error {
+	const op = "storage.postgresql.SaveUserPhotoThumbnails"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	thumbnailKeys, err := s.putThumbnails(ctx, institute, thumbnails)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var oldThumbnailKeys []string
+	err = s.withSchema(ctx, institute, false, func(tx *sql.Tx) error {
+		var err error
+		oldThumbnailKeys, err = insertThumbnails(ctx, tx, id, thumbnailKeys)
+		return err
+	})
+	if err != nil {
+		s.releaseBlobs(ctx, slices.Collect(maps.Values(thumbnailKeys)))
+		if err == storage.ErrUserNotFound {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.releaseBlobs(ctx, oldThumbnailKeys)
+
 	return nil
 }
+
+// replaceThumbnails заменяет все миниатюры работника; возвращает ключи объектов прежних
+func replaceThumbnails(ctx context.Context, tx *sql.Tx, id int, keys map[int]string) ([]string, error) {
+	old, err := deleteThumbnails(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := insertThumbnails(ctx, tx, id, keys); err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
+// deleteThumbnails удаляет миниатюры работника и возвращает ключи их объектов
+func deleteThumbnails(ctx context.Context, tx *sql.Tx, id int) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `DELETE FROM photo_thumbnails WHERE worker_id = $1 RETURNING key`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete thumbnails: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan thumbnail key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete thumbnails: %w", err)
+	}
+
+	return keys, nil
+}
+
+// insertThumbnails записывает ключи миниатюр по размеру; возвращает ключи заменённых
+func insertThumbnails(ctx context.Context, tx *sql.Tx, id int, keys map[int]string) ([]string, error) {
+	query := `
+		WITH old AS (SELECT key FROM photo_thumbnails WHERE worker_id = $1 AND size = $2)
+		INSERT INTO photo_thumbnails (worker_id, size, key) VALUES ($1, $2, $3)
+		ON CONFLICT (worker_id, size) DO UPDATE SET key = EXCLUDED.key
+		RETURNING (SELECT key FROM old)`
+
+	var replaced []string
+	for size, key := range keys {
+		var oldKey sql.NullString
+		if err := tx.QueryRowContext(ctx, query, id, size, key).Scan(&oldKey); err != nil {
+			// Работника удалили, пока строилась миниатюра
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return nil, storage.ErrUserNotFound
+			}
+			return nil, fmt.Errorf("failed to save %dpx thumbnail: %w", size, err)
+		}
+		if oldKey.Valid && oldKey.String != key {
+			replaced = append(replaced, oldKey.String)
+		}
+	}
+
+	return replaced, nil
+}
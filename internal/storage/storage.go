@@ -3,7 +3,27 @@ package storage
 import "errors"
 
 var (
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrSchemaNotExist    = errors.New("schema not exists")
+	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrSchemaNotExist       = errors.New("schema not exists")
+	ErrImportJobNotFound    = errors.New("import job not found")
+	ErrImportJobLost        = errors.New("import job is no longer held by this instance")
+	ErrImportQueueFull      = errors.New("import queue is full")
+	ErrThumbnailNotFound    = errors.New("thumbnail not found")
+	ErrPendingPhotoNotFound = errors.New("pending photo not found")
+	ErrGrantNotFound        = errors.New("permission grant not found")
+	ErrGrantAlreadyExists   = errors.New("permission grant already exists")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
 )
+
+// Schema каноническое имя института (и его схемы БД) по любому из принятых написаний
+func Schema(institute string) (string, error) {
+	switch institute {
+	case "grafit", "графит", "Графит", "Grafit":
+		return "grafit", nil
+	case "giredmet", "Giredmet", "гиредмет", "Гиредмет":
+		return "giredmet", nil
+	default:
+		return "", ErrSchemaNotExist
+	}
+}
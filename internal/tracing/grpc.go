@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor открывает спан на вызов SSO и передаёт контекст трассировки в метаданных.
+// Ставится первым в цепочке, чтобы спан охватывал повторы, логирование и метрики
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// method вида /auth.Auth/IsAdmin
+		service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+		ctx, span := Start(ctx, strings.TrimPrefix(method, "/"),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCService(service),
+				semconv.RPCMethod(name),
+				semconv.ServerAddress(cc.Target()),
+			),
+		)
+		defer span.End()
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		st := status.Convert(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+		if err != nil {
+			span.SetStatus(codes.Error, st.Message())
+		}
+		return err
+	}
+}
+
+// metadataCarrier gRPC метаданные как носитель traceparent
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
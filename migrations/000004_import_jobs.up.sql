@@ -0,0 +1,21 @@
+-- Фоновые задачи импорта работников
+CREATE TABLE IF NOT EXISTS public.import_jobs
+(
+    id             BIGSERIAL PRIMARY KEY,
+    institute      TEXT        NOT NULL,
+    format         TEXT        NOT NULL,
+    status         TEXT        NOT NULL,
+    progress       INT         NOT NULL DEFAULT 0,
+    total_rows     INT         NOT NULL DEFAULT 0,
+    processed_rows INT         NOT NULL DEFAULT 0,
+    imported_rows  INT         NOT NULL DEFAULT 0,
+    failed_rows    INT         NOT NULL DEFAULT 0,
+    errors         JSONB       NOT NULL DEFAULT '[]',
+    error          TEXT,
+    created_by     BIGINT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_status_idx ON public.import_jobs (status);
@@ -0,0 +1,5 @@
+SET search_path TO grafit;
+ALTER TABLE workers DROP COLUMN IF EXISTS personnel_number;
+
+SET search_path TO giredmet;
+ALTER TABLE workers DROP COLUMN IF EXISTS personnel_number;
@@ -0,0 +1,7 @@
+-- Табельный номер работника, по нему сопоставляются фотографии при массовой загрузке
+
+SET search_path TO grafit;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS personnel_number TEXT UNIQUE;
+
+SET search_path TO giredmet;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS personnel_number TEXT UNIQUE;
@@ -0,0 +1,2 @@
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS created_org_units;
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS missing_org_units;
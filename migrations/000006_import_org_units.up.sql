@@ -0,0 +1,3 @@
+-- Обработка отсутствующих отделов и секций при импорте
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS missing_org_units TEXT NOT NULL DEFAULT 'keep';
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS created_org_units JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,5 @@
+SET search_path TO grafit;
+DROP TABLE IF EXISTS photo_thumbnails;
+
+SET search_path TO giredmet;
+DROP TABLE IF EXISTS photo_thumbnails;
@@ -0,0 +1,19 @@
+-- Миниатюры фотографий работников, строятся при загрузке фото или при первом запросе размера
+
+SET search_path TO grafit;
+CREATE TABLE IF NOT EXISTS photo_thumbnails
+(
+    worker_id INT   NOT NULL REFERENCES workers (id) ON DELETE CASCADE,
+    size      INT   NOT NULL,
+    data      BYTEA NOT NULL,
+    PRIMARY KEY (worker_id, size)
+);
+
+SET search_path TO giredmet;
+CREATE TABLE IF NOT EXISTS photo_thumbnails
+(
+    worker_id INT   NOT NULL REFERENCES workers (id) ON DELETE CASCADE,
+    size      INT   NOT NULL,
+    data      BYTEA NOT NULL,
+    PRIMARY KEY (worker_id, size)
+);
@@ -0,0 +1,9 @@
+-- Перед откатом фотографии нужно вернуть в колонку photo, иначе ссылки на объекты будут потеряны
+
+SET search_path TO grafit;
+DROP INDEX IF EXISTS workers_photo_filename_idx;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_hash;
+
+SET search_path TO giredmet;
+DROP INDEX IF EXISTS workers_photo_filename_idx;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_hash;
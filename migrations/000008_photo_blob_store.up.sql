@@ -0,0 +1,12 @@
+-- Фотографии переезжают в BlobStore: в строке работника остаются хеш содержимого и ключ объекта (photo_filename).
+-- Колонка photo остаётся, пока cmd/blob_migrator не перенесёт старые фотографии.
+
+SET search_path TO grafit;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_filename TEXT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_hash TEXT;
+CREATE INDEX IF NOT EXISTS workers_photo_filename_idx ON workers (photo_filename);
+
+SET search_path TO giredmet;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_filename TEXT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_hash TEXT;
+CREATE INDEX IF NOT EXISTS workers_photo_filename_idx ON workers (photo_filename);
@@ -0,0 +1,11 @@
+SET search_path TO grafit;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_x;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_y;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_width;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_height;
+
+SET search_path TO giredmet;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_x;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_y;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_width;
+ALTER TABLE workers DROP COLUMN IF EXISTS photo_crop_height;
@@ -0,0 +1,13 @@
+-- Область кадрирования фотографии в пикселях оригинала; NULL — кадрирование по умолчанию
+
+SET search_path TO grafit;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_x INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_y INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_width INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_height INT;
+
+SET search_path TO giredmet;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_x INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_y INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_width INT;
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS photo_crop_height INT;
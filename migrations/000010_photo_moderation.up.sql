@@ -0,0 +1,33 @@
+-- Фотографии от не-администраторов, ожидающие одобрения; у работника не больше одной
+
+SET search_path TO grafit;
+CREATE TABLE IF NOT EXISTS pending_photos
+(
+    id                SERIAL PRIMARY KEY,
+    worker_id         INT         NOT NULL UNIQUE REFERENCES workers (id) ON DELETE CASCADE,
+    photo_hash        TEXT        NOT NULL,
+    photo_filename    TEXT        NOT NULL,
+    photo_crop_x      INT,
+    photo_crop_y      INT,
+    photo_crop_width  INT,
+    photo_crop_height INT,
+    submitted_by      BIGINT,
+    submitted_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS pending_photos_photo_filename_idx ON pending_photos (photo_filename);
+
+SET search_path TO giredmet;
+CREATE TABLE IF NOT EXISTS pending_photos
+(
+    id                SERIAL PRIMARY KEY,
+    worker_id         INT         NOT NULL UNIQUE REFERENCES workers (id) ON DELETE CASCADE,
+    photo_hash        TEXT        NOT NULL,
+    photo_filename    TEXT        NOT NULL,
+    photo_crop_x      INT,
+    photo_crop_y      INT,
+    photo_crop_width  INT,
+    photo_crop_height INT,
+    submitted_by      BIGINT,
+    submitted_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS pending_photos_photo_filename_idx ON pending_photos (photo_filename);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.revoked_sessions;
+DROP TABLE IF EXISTS public.revoked_tokens;
@@ -0,0 +1,18 @@
+-- Отозванные токены SSO: отдельные токены (выход) и все сессии пользователя до момента отзыва
+
+CREATE TABLE IF NOT EXISTS public.revoked_tokens
+(
+    token_hash TEXT PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON public.revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS public.revoked_sessions
+(
+    user_id        BIGINT PRIMARY KEY,
+    revoked_before TIMESTAMPTZ NOT NULL,
+    revoked_by     BIGINT
+);
@@ -0,0 +1,17 @@
+-- Права пользователей SSO в пределах института или отдела.
+-- department пустой — право на весь институт.
+
+CREATE TABLE IF NOT EXISTS public.permissions
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    role       TEXT        NOT NULL CHECK (role IN ('admin', 'editor')),
+    institute  TEXT        NOT NULL,
+    department TEXT        NOT NULL DEFAULT '',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (role <> 'admin' OR department = '')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS permissions_grant_idx ON public.permissions (user_id, role, institute, department);
+CREATE INDEX IF NOT EXISTS permissions_institute_idx ON public.permissions (institute);
@@ -0,0 +1,16 @@
+-- Ключи API для сервисов (АТС, портал, синхронизация с кадрами); хранится только sha256 ключа
+
+CREATE TABLE IF NOT EXISTS public.api_keys
+(
+    id           BIGSERIAL PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL UNIQUE,
+    -- Начало ключа, чтобы его можно было узнать в списке
+    prefix       TEXT        NOT NULL,
+    access       TEXT        NOT NULL CHECK (access IN ('read', 'read_write')),
+    institutes   TEXT[]      NOT NULL DEFAULT '{}',
+    created_by   BIGINT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
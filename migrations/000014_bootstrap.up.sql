@@ -0,0 +1,11 @@
+-- Отметка о том, что первый администратор уже создан; строка может быть только одна
+
+CREATE TABLE IF NOT EXISTS public.bootstrap
+(
+    singleton     BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    admin_email   TEXT        NOT NULL,
+    admin_user_id BIGINT,
+    -- Как создан администратор: config, setup_token или cli
+    method        TEXT        NOT NULL,
+    completed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
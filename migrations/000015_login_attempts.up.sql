@@ -0,0 +1,11 @@
+-- Неудачные попытки входа для ограничения перебора паролей, общие для всех экземпляров сервиса
+
+CREATE TABLE IF NOT EXISTS public.login_attempts
+(
+    key          TEXT PRIMARY KEY,
+    failures     INTEGER     NOT NULL DEFAULT 0,
+    last_failure TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_last_failure_idx ON public.login_attempts (last_failure);
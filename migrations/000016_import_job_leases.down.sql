@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.import_jobs_owner_idx;
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS lease_until;
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,7 @@
+-- Аренда задач импорта: экземпляр сервиса, который выполняет задачу, продлевает lease_until,
+-- а задачи с истёкшей арендой считаются оборванными. cancel_requested — отмена из другого экземпляра
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS owner TEXT;
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS import_jobs_owner_idx ON public.import_jobs (owner) WHERE finished_at IS NULL;
@@ -0,0 +1,9 @@
+-- Объекты фотографий, на которые перестали ссылаться. Удаляются фоновым проходом после
+-- выдержки, если к тому времени на них снова не сослались
+CREATE TABLE IF NOT EXISTS public.photo_orphans
+(
+    key         TEXT PRIMARY KEY,
+    released_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS photo_orphans_released_at_idx ON public.photo_orphans (released_at);
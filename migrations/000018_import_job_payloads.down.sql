@@ -0,0 +1 @@
+ALTER TABLE public.import_jobs DROP COLUMN IF EXISTS payload_key;
@@ -0,0 +1,3 @@
+-- Файл импорта хранится в BlobStore под payload_key до завершения задачи: очередь переживает
+-- перезапуск, а задачу из очереди может взять любой экземпляр сервиса
+ALTER TABLE public.import_jobs ADD COLUMN IF NOT EXISTS payload_key TEXT;
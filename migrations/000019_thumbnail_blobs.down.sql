@@ -0,0 +1,13 @@
+-- Объекты миниатюр в BlobStore остаются; в таблицу они не возвращаются и строятся заново
+
+SET search_path TO grafit;
+DELETE FROM photo_thumbnails;
+DROP INDEX IF EXISTS photo_thumbnails_key_idx;
+ALTER TABLE photo_thumbnails DROP COLUMN IF EXISTS key;
+ALTER TABLE photo_thumbnails ADD COLUMN IF NOT EXISTS data BYTEA NOT NULL;
+
+SET search_path TO giredmet;
+DELETE FROM photo_thumbnails;
+DROP INDEX IF EXISTS photo_thumbnails_key_idx;
+ALTER TABLE photo_thumbnails DROP COLUMN IF EXISTS key;
+ALTER TABLE photo_thumbnails ADD COLUMN IF NOT EXISTS data BYTEA NOT NULL;
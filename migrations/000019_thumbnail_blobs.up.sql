@@ -0,0 +1,14 @@
+-- Миниатюры переезжают в BlobStore: в таблице остаётся ключ объекта. Старые миниатюры
+-- не переносятся, а удаляются: обработчик фото построит их заново при первом запросе
+
+SET search_path TO grafit;
+DELETE FROM photo_thumbnails;
+ALTER TABLE photo_thumbnails DROP COLUMN IF EXISTS data;
+ALTER TABLE photo_thumbnails ADD COLUMN IF NOT EXISTS key TEXT NOT NULL;
+CREATE INDEX IF NOT EXISTS photo_thumbnails_key_idx ON photo_thumbnails (key);
+
+SET search_path TO giredmet;
+DELETE FROM photo_thumbnails;
+ALTER TABLE photo_thumbnails DROP COLUMN IF EXISTS data;
+ALTER TABLE photo_thumbnails ADD COLUMN IF NOT EXISTS key TEXT NOT NULL;
+CREATE INDEX IF NOT EXISTS photo_thumbnails_key_idx ON photo_thumbnails (key);
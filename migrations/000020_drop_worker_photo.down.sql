@@ -0,0 +1,3 @@
+-- Колонка возвращается пустой: фотографии остаются в BlobStore
+ALTER TABLE grafit.workers ADD COLUMN IF NOT EXISTS photo BYTEA;
+ALTER TABLE giredmet.workers ADD COLUMN IF NOT EXISTS photo BYTEA;
@@ -0,0 +1,15 @@
+-- Фотографии хранятся только в BlobStore. Колонку workers.photo можно удалить после того,
+-- как cmd/blob_migrator (task MovePhotos) перенёс из неё все фотографии; иначе миграция
+-- останавливается, чтобы не потерять их
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM grafit.workers WHERE photo IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM giredmet.workers WHERE photo IS NOT NULL) THEN
+        RAISE EXCEPTION 'workers.photo still holds photos: run cmd/blob_migrator before this migration';
+    END IF;
+END
+$$;
+
+ALTER TABLE grafit.workers DROP COLUMN IF EXISTS photo;
+ALTER TABLE giredmet.workers DROP COLUMN IF EXISTS photo;